@@ -98,15 +98,26 @@ func ExportApi(in ExportApiIn) (errChan chan error, err error) {
 	if deleteFileDelay == 0 {
 		deleteFileDelay = 24 * time.Hour //默认24小时后删除文件
 	}
-	proxyReq := in.ProxyRquest
-	proxyRsp := in.ProxyResponse
 	filename := settings.Filename
-	ecw := NewExcelStreamWriter(ctx, filename).WithFieldMetas(in.Settings.FieldMetas)
+	ecw := NewExcelStreamWriter(ctx, filename).WithFieldMetas(in.Settings.FieldMetas).WithSheetOptions(settings.SheetOptions).WithTemplateData(settings.TemplateData).WithImageLoader(settings.ImageLoader)
+	fetcher, maxLoopTimes, err := makeExportFetcher(ecw, in.ProxyRquest, in.ProxyResponse)
+	if err != nil {
+		return nil, err
+	}
+	ecw = ecw.WithInterval(settings.Interval).WithDeleteFile(deleteFileDelay, nil).WithMaxLoopCount(maxLoopTimes).WithFetcher(fetcher)
+	errChan, err = ecw.Run()
+	return errChan, err
+}
+
+// makeExportFetcher 生成代理接口分页数据获取器,未设置字段元数据时按接口返回推断列(写入 ecw)
+func makeExportFetcher(ecw *ExcelStreamWriter, proxyReq ProxyRquest, proxyRsp ProxyResponse) (fetcher FetcherFn, maxLoopTimes int, err error) {
+	sheetOptions := ecw.sheetOptions
+	inferFieldMetas := ecw.fieldMetas.Empty()
 	startIndex := 0
 	startIndexRaw := ""
 	exp := regexp.MustCompile(`\d+`)
 	_rowNumber := 0
-	requestDTODefault := in.ProxyRquest.RequestDTO
+	requestDTODefault := proxyReq.RequestDTO
 	bodyDefault := []byte(requestDTODefault.Body) // body 的pageSize 有可能会被修改，所以在这里赋值
 
 	if proxyReq.PageIndexPath != "" {
@@ -114,7 +125,7 @@ func ExportApi(in ExportApiIn) (errChan chan error, err error) {
 		result := gjson.GetBytes(bodyDefault, proxyReq.PageIndexPath)
 		if !result.Exists() {
 			err = errors.Errorf("pageIndexPath:%s (not found in body(%s))", proxyReq.PageIndexPath, bodyDefault)
-			return nil, err
+			return nil, 0, err
 		}
 		startIndex = int(result.Int())
 		if proxyReq.PageIndexStart != "" { // 配置中有，则优先使用配置中的起始值，这样可以避免前端翻页到第二页后点击导出，导致导出数据不全的问题。
@@ -135,20 +146,25 @@ func ExportApi(in ExportApiIn) (errChan chan error, err error) {
 					raw := exp.ReplaceAllString(result.Raw, cast.ToString(pageSize))                      // 确保类型一致
 					bodyDefault, err = sjson.SetRawBytes(bodyDefault, proxyReq.PageSizePath, []byte(raw)) //修改body 的pageSize字段值
 					if err != nil {
-						return nil, err
+						return nil, 0, err
 					}
 				}
 			}
 		}
 	}
-	maxLoopTimes := MaxLoopTimes
+	maxLoopTimes = MaxLoopTimes
 	if proxyReq.PageIndexPath == "" { //不带页码占位符，则只获取一次数据
 		maxLoopTimes = 1 // 只获取一次数据
 	}
 
 	requestDTODefault.Body = string(bodyDefault)
+	sendFn := proxyReq.SendFn
+	if sendFn == nil {
+		sendFn = defaultProxySendFn(proxyReq.MiddlewareFuncs, proxyRsp.MiddlewareFuncs)
+	}
 
-	ecw = ecw.WithInterval(settings.Interval).WithDeleteFile(deleteFileDelay, nil).WithMaxLoopCount(maxLoopTimes).WithFetcher(func(loopTimes int) (rows []map[string]string, err error) {
+	// fetchPage 获取一页数据,dataCount 为接口返回的原始记录数(过滤前)
+	fetchPage := func(loopTimes int) (rows []map[string]string, dataCount int, err error) {
 		pageIndexDelta := loopTimes - 1
 		requestDTO := requestDTODefault
 		if proxyReq.PageIndexPath != "" {
@@ -156,77 +172,172 @@ func ExportApi(in ExportApiIn) (errChan chan error, err error) {
 			indexRaw := exp.ReplaceAllString(startIndexRaw, cast.ToString(pageIndex)) // 确保类型一致
 			requestDTO.Body, err = sjson.SetRaw(requestDTO.Body, proxyReq.PageIndexPath, indexRaw)
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 		}
 
-		if in.ProxyRquest.RequestFormatFn != nil {
-			newRequestDTO, err := in.ProxyRquest.RequestFormatFn(requestDTO)
+		if proxyReq.RequestFormatFn != nil {
+			newRequestDTO, err := proxyReq.RequestFormatFn(requestDTO)
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 			requestDTO = newRequestDTO
 		}
 
-		client := apihttpprotocol.NewClientProtocol(requestDTO.Method, requestDTO.URL)
-		client.Request().AddMiddleware(proxyReq.MiddlewareFuncs...)
-		client.Response().AddMiddleware(proxyRsp.MiddlewareFuncs...)
-		client.Request().Headers = requestDTO.Headers.HttpHeaders() //设置头
-
-		var resp json.RawMessage
-		newBody := json.RawMessage([]byte(requestDTO.Body))
-		err = client.Do(newBody, &resp)
-		curlCommand := client.Request().CurlCommand()
+		resp, curlCommand, err := sendFn(ecw.context, requestDTO)
 		if err != nil {
-			err = errors.WithMessagef(err, "curl:%s", curlCommand)
-			return nil, err
+			return nil, 0, err
 		}
-		if in.ProxyResponse.BusinessCodePath != "" {
-			businessCode := gjson.GetBytes(resp, in.ProxyResponse.BusinessCodePath).String()
-			if businessCode != cast.ToString(in.ProxyResponse.BusinessOkCode) {
-				err = ProxyResponseError{
-					ExpattedBusinessCode: in.ProxyResponse.BusinessOkCode,
-					ActualBusinessCode:   businessCode,
-					Url:                  requestDTODefault.URL,
-					Response:             string(resp),
-					CurlCommand:          curlCommand,
-				}
-				return nil, err
-			}
+		err = checkBusinessCode(resp, proxyRsp.BusinessCodePath, proxyRsp.BusinessOkCode, requestDTODefault.URL, curlCommand)
+		if err != nil {
+			return nil, 0, err
 		}
 		data := gjson.GetBytes(resp, proxyRsp.DataPath).Array()
 
-		if len(ecw.fieldMetas) == 0 && len(data) > 0 { // 没有传入字段元数据，则自动从第一行获取字段名作为标题
-			firstRow := data[0]
-			fieldMetas := make([]defined.FieldMeta, 0)
-			for key := range firstRow.Map() {
-				fieldMetas = append(fieldMetas, defined.FieldMeta{Name: key, Title: key})
+		if inferFieldMetas && len(data) > 0 { // 没有传入字段元数据，则按接口返回字段顺序自动推断列
+			newFieldMetas := InferFieldMetas(data, ecw.fieldMetas, sheetOptions.HumanizeTitle)
+			switch {
+			case len(ecw.fieldMetas) == 0:
+				ecw = ecw.WithFieldMetas(newFieldMetas)
+			case len(newFieldMetas) > 0 && sheetOptions.InferFieldPolicy == defined.InferFieldPolicy_append:
+				ecw.AppendFieldMetas(newFieldMetas...)
 			}
-			ecw = ecw.WithFieldMetas(fieldMetas)
 		}
 
-		items := make([]map[string]string, 0)
+		rowMaps := make([]map[string]string, 0, len(data))
 		for _, row := range data {
-			_rowNumber++
-			rowMap := make(map[string]string)
-			rowMap["_rowNumber"] = cast.ToString(_rowNumber)
-			for k, v := range row.Map() {
-				rowMap[k] = v.String()
+			rowMaps = append(rowMaps, MakeRecords(row, ecw.fieldMetas, proxyRsp.FlattenPath)...)
+		}
+
+		items := make([]map[string]string, 0)
+		for i, rowMap := range rowMaps {
+			rowMap["_rowNumber"] = cast.ToString(_rowNumber + i + 1) // 过滤、展开前的临时序号,方便钩子函数使用
+			if proxyRsp.RecordFormatFn != nil {
+				rowMap, err = proxyRsp.RecordFormatFn(rowMap)
+				if err != nil {
+					return nil, 0, err
+				}
+			}
+			if rowMap == nil { // 返回nil 视为过滤该记录
+				continue
 			}
-			if in.ProxyResponse.RecordFormatFn != nil {
-				rowMap, err = in.ProxyResponse.RecordFormatFn(rowMap)
+			records := []map[string]string{rowMap}
+			if proxyRsp.RecordsFormatFn != nil {
+				records, err = proxyRsp.RecordsFormatFn(rowMap)
 				if err != nil {
-					return nil, err
+					return nil, 0, err
+				}
+			}
+			for _, record := range records {
+				if record != nil {
+					items = append(items, record)
 				}
 			}
-			items = append(items, rowMap)
 		}
-		return items, nil
-	})
-	errChan, err = ecw.Run()
+		for _, item := range items { // 过滤、展开后重新编号
+			_rowNumber++
+			item["_rowNumber"] = cast.ToString(_rowNumber)
+		}
+		return items, len(data), nil
+	}
+	skippedPages := 0 // 整页记录被过滤时跳过的页数
+	fetcher = func(loopTimes int) (rows []map[string]string, err error) {
+		for {
+			if loopTimes+skippedPages > maxLoopTimes {
+				err = errors.Errorf("loop times is over limit:%d", maxLoopTimes)
+				return nil, err
+			}
+			rows, dataCount, err := fetchPage(loopTimes + skippedPages)
+			if err != nil {
+				return nil, err
+			}
+			if len(rows) == 0 && dataCount > 0 && proxyReq.PageIndexPath != "" { // 整页记录被过滤,继续获取下一页,避免提前结束导出
+				skippedPages++
+				continue
+			}
+			return rows, nil
+		}
+	}
+	return fetcher, maxLoopTimes, nil
+}
+
+// ExportSheetsApi 多表单导出到同一个Excel文件,每个表单独立请求数据,表单错误通过 SheetErrors 返回
+func ExportSheetsApi(in ExportSheetsApiIn) (errChan chan error, err error) {
+	err = validator.New().Struct(in)
+	if err != nil {
+		return nil, err
+	}
+	deleteFileDelay := in.DeleteFileDelay
+	if deleteFileDelay == 0 {
+		deleteFileDelay = 24 * time.Hour //默认24小时后删除文件
+	}
+	workbook := NewExcelWorkbookWriter(context.Background(), in.Filename).WithParallel(in.Parallel)
+	for _, sheet := range in.Sheets {
+		ecw, err := workbook.AddSheet(sheet.Name)
+		if err != nil {
+			return nil, err
+		}
+		ecw = ecw.WithFieldMetas(sheet.FieldMetas).WithSheetOptions(sheet.SheetOptions).WithImageLoader(in.ImageLoader)
+		fetcher, maxLoopTimes, err := makeExportFetcher(ecw, sheet.ProxyRquest, sheet.ProxyResponse)
+		if err != nil { // 与获取数据错误一致,通过 SheetErrors 返回,不影响其它表单导出
+			setupErr := err
+			fetcher, maxLoopTimes = func(loopTimes int) (rows []map[string]string, err error) { return nil, setupErr }, 1
+		}
+		ecw.WithInterval(in.Interval).WithMaxLoopCount(maxLoopTimes).WithFetcher(fetcher)
+	}
+	errChan, err = workbook.WithDeleteFile(deleteFileDelay, nil).Run()
 	return errChan, err
 }
 
+// ProxySendFn 代理请求发送函数,返回响应体及 curl 命令(用于错误信息),为空时使用 http 客户端发送
+type ProxySendFn func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error)
+
+// defaultProxySendFn 使用 http 客户端发送代理请求
+func defaultProxySendFn(requestMiddlewares apihttpprotocol.MiddlewareFuncsRequestMessage, responseMiddlewares apihttpprotocol.MiddlewareFuncsResponseMessage) ProxySendFn {
+	return func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+		return doProxyRequest(ctx, requestDTO, requestMiddlewares, responseMiddlewares)
+	}
+}
+
+// doProxyRequest 发送代理请求,ctx 已取消时不再发送;http 客户端不支持 context,已发出的请求等待完成后返回(不在后台遗留请求,返回结果与服务端一致)
+func doProxyRequest(ctx context.Context, requestDTO httpraw.RequestDTO, requestMiddlewares apihttpprotocol.MiddlewareFuncsRequestMessage, responseMiddlewares apihttpprotocol.MiddlewareFuncsResponseMessage) (resp json.RawMessage, curlCommand string, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	client := apihttpprotocol.NewClientProtocol(requestDTO.Method, requestDTO.URL)
+	client.Request().AddMiddleware(requestMiddlewares...)
+	client.Response().AddMiddleware(responseMiddlewares...)
+	client.Request().Headers = requestDTO.Headers.HttpHeaders() //设置头
+
+	newBody := json.RawMessage([]byte(requestDTO.Body))
+	err = client.Do(newBody, &resp)
+	curlCommand = client.Request().CurlCommand()
+	if err != nil {
+		err = errors.WithMessagef(err, "curl:%s", curlCommand)
+		return nil, curlCommand, err
+	}
+	return resp, curlCommand, nil
+}
+
+// checkBusinessCode 校验业务成功标识,businessCodePath 为空时不校验
+func checkBusinessCode(resp json.RawMessage, businessCodePath string, businessOkCode string, url string, curlCommand string) (err error) {
+	if businessCodePath == "" {
+		return nil
+	}
+	businessCode := gjson.GetBytes(resp, businessCodePath).String()
+	if businessCode != cast.ToString(businessOkCode) {
+		err = ProxyResponseError{
+			ExpattedBusinessCode: businessOkCode,
+			ActualBusinessCode:   businessCode,
+			Url:                  url,
+			Response:             string(resp),
+			CurlCommand:          curlCommand,
+		}
+		return err
+	}
+	return nil
+}
+
 type ProxyResponseError struct {
 	ExpattedBusinessCode string `json:"expattedBusinessCode"`
 	ActualBusinessCode   string `json:"actualBusinessCode"`
@@ -270,21 +381,27 @@ type ProxyRquest struct {
 	PageSize        int                                           `json:"pageSize"`       //每页数量，例如：100
 	MiddlewareFuncs apihttpprotocol.MiddlewareFuncsRequestMessage `json:"-"`              // 请求中间件函数列表，一般可以使用动态脚本生成
 	RequestFormatFn defined.RequestFormatFn                       `json:"-"`              //请求格式化函数，例如：func(request httpraw.RequestDTO)(newRequest httpraw.RequestDTO,err error){ return request,nil}
+	SendFn          ProxySendFn                                   `json:"-"`              //自定义发送函数,为空时使用 http 客户端
 }
 
 type ProxyResponse struct {
 	DataPath         string                                         `json:"dataPath"  validate:"required"`
+	FlattenPath      string                                         `json:"flattenPath"`      //展开数组路径(相对DataPath 下每条数据)，例如：items,设置后每个数组元素输出一行,父级列重复,子元素列使用 items.#.sku 引用
 	BusinessCodePath string                                         `json:"businessCodePath"` //业务成功标识路径，例如：$.code
 	BusinessOkCode   string                                         `json:"businessOkCode"`   //业务成功标识值，例如：0
 	MiddlewareFuncs  apihttpprotocol.MiddlewareFuncsResponseMessage `json:"-"`                // 请求中间件函数列表，一般可以使用动态脚本生成
 	RecordFormatFn   defined.RecordFormatFn                         //格式化记录函数，例如：func(record map[string]string)(newRecord map[string]string,err error){ return record,nil}
+	RecordsFormatFn  defined.RecordsFormatFn                        //记录过滤、展开函数(在RecordFormatFn之后执行),返回0条过滤记录,返回多条展开记录,_rowNumber 会在处理后重新编号
 }
 
 type Settings struct {
-	Filename        string             `json:"filename" validate:"required"` //导出文件全称如 /static/export/20231018_1547.xlsx
-	FieldMetas      defined.FieldMetas `json:"fieldMetas"`                   //字段映射信息{"id":"ID","name":"姓名"}
-	Interval        time.Duration      `json:"interval"`
-	DeleteFileDelay time.Duration      `json:"deleteFileDelay"`
+	Filename        string              `json:"filename" validate:"required"` //导出文件全称如 /static/export/20231018_1547.xlsx
+	FieldMetas      defined.FieldMetas  `json:"fieldMetas"`                   //字段映射信息{"id":"ID","name":"姓名"}
+	Interval        time.Duration       `json:"interval"`
+	DeleteFileDelay time.Duration       `json:"deleteFileDelay"`
+	TemplateData    map[string]any      `json:"templateData"` //模板占位符数据(设置了模板文件时使用)，例如：{"title":"订单报表"}
+	ImageLoader     defined.ImageLoader `json:"-"`            //图片加载函数(type=image 的列),为空时不加载图片，例如：excelrw.NewHttpImageLoader(allow, 0)
+	defined.SheetOptions
 }
 
 type ExportApiIn struct {
@@ -294,6 +411,25 @@ type ExportApiIn struct {
 	//CallBackFns   []CallBackFnV2 `json:"-"`                                 //回调函数列表，例如：func(fileUrl string)(err error){ return nil}
 }
 
+// ExportSheet 表单导出配置
+type ExportSheet struct {
+	Name          string             `json:"name" validate:"required"`          //表单名称，例如：订单
+	ProxyRquest   ProxyRquest        `json:"proxyRequest" validate:"required"`  //请求数据参数
+	ProxyResponse ProxyResponse      `json:"proxyResponse" validate:"required"` //响应数据参数
+	FieldMetas    defined.FieldMetas `json:"fieldMetas"`                        //字段映射信息
+	defined.SheetOptions
+}
+
+// ExportSheetsApiIn 多表单导出参数
+type ExportSheetsApiIn struct {
+	Sheets          []ExportSheet       `json:"sheets" validate:"required,min=1,dive"`
+	Filename        string              `json:"filename" validate:"required"` //导出文件全称如 /static/export/20231018_1547.xlsx
+	Interval        time.Duration       `json:"interval"`
+	DeleteFileDelay time.Duration       `json:"deleteFileDelay"`
+	Parallel        bool                `json:"parallel"` //表单并行获取数据,默认按顺序导出
+	ImageLoader     defined.ImageLoader `json:"-"`        //图片加载函数(type=image 的列),为空时不加载图片
+}
+
 const (
 	MaxLoopTimes = 50000 //最大循环次数，防止死循环
 )
@@ -305,6 +441,8 @@ type MakeExportApiInArgs struct {
 	Filename  string   `json:"filename"`  //导出文件全称如 /static/export/20231018_1547.xlsx
 	Request   Request  `json:"request"`   //请求数据参数
 	response  Response `json:"-"`         //响应数据参数,只用于收集中间件,不对外开放
+
+	DictLoader defined.DictLoader `json:"-"` //共享字典加载函数,字段元数据引用了 dictKey 时必须,例如：repository.ExportDictRepository.DictLoader()
 }
 
 type Request struct {
@@ -317,9 +455,12 @@ type Request struct {
 type Response struct {
 	MiddlewareFuncs apihttpprotocol.MiddlewareFuncsResponseMessage `json:"-"` // 请求中间件函数列表，一般可以使用动态脚本生成
 	RecordFormatFn  defined.RecordFormatFn                         `json:"-"` //格式化记录函数，例如：func(record map[string]string)(newRecord map[string]string,err error){ return record,nil}
+	RecordsFormatFn defined.RecordsFormatFn                        `json:"-"` //记录过滤、展开函数，例如：func(record map[string]string)(newRecords []map[string]string,err error){ return nil,nil}
 }
 
 var Export_config_table sqlbuilder.TableConfig = repository.Export_config_table
+var Import_config_table sqlbuilder.TableConfig = repository.Import_config_table
+var Import_task_table sqlbuilder.TableConfig = repository.Import_task_table
 var IdTimeColumns = repository.IdTimeColumns
 var IdIndex = repository.IdIndex
 
@@ -361,6 +502,10 @@ func MakeExportApiIn(in MakeExportApiInArgs, config repository.ExportConfigModel
 	if err != nil {
 		return exportApiIn, err
 	}
+	err = fieldMetas.LoadDicts(in.DictLoader)
+	if err != nil {
+		return exportApiIn, err
+	}
 	tnterval, err := config.ParseInterval()
 	if err != nil {
 		return exportApiIn, err
@@ -369,13 +514,17 @@ func MakeExportApiIn(in MakeExportApiInArgs, config repository.ExportConfigModel
 	if err != nil {
 		return exportApiIn, err
 	}
+	sheetOptions, err := config.ParseSheetOptions()
+	if err != nil {
+		return exportApiIn, err
+	}
 
 	dynamicFn, err := config.ParseDynamicScript()
 	if err != nil {
 		return exportApiIn, err
 	}
 	in.Request.RequestFormatFn = dynamicFn.RequestFormatFn
-	in.response.RecordFormatFn = dynamicFn.RecordFormatFn
+	in.response.RecordsFormatFn = dynamicFn.RecordsFormatFn // js recordFormatFn 支持返回null、数组,统一使用多条记录处理函数
 
 	reqDTO, err := config.RenderRequestDTO(data, requestBody)
 	if err != nil {
@@ -399,16 +548,25 @@ func MakeExportApiIn(in MakeExportApiInArgs, config repository.ExportConfigModel
 		}, //请求数据参数
 		ProxyResponse: ProxyResponse{
 			DataPath:         config.DataPath,
+			FlattenPath:      config.FlattenPath,
 			BusinessCodePath: config.BusinessCodePath,
 			BusinessOkCode:   config.BusinessOkCode,
 			MiddlewareFuncs:  in.response.MiddlewareFuncs,
 			RecordFormatFn:   in.response.RecordFormatFn,
+			RecordsFormatFn:  in.response.RecordsFormatFn,
 		}, //响应数据参数
 		Settings: Settings{
 			Filename:        filename,
 			FieldMetas:      fieldMetas,
 			Interval:        tnterval,
 			DeleteFileDelay: deleteFileDelay,
+			SheetOptions:    sheetOptions,
+			TemplateData: map[string]any{ // 模板占位符可引用请求参数，例如：{{request.shopName}}
+				"creatorId": in.CreatorId,
+				"filename":  filename,
+				"datetime":  time.Now().Local().Format(defined.DatetimeLayout),
+				"request":   requestBody,
+			},
 		}, //配置信息
 	}
 	return exportApiIn, nil
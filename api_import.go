@@ -0,0 +1,438 @@
+package excelrw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/apihttpprotocol"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/suifengpiao14/excelrw/repository"
+	"github.com/suifengpiao14/httpraw"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	ImportBatchSize_default     = 100         //导入每批提交行数
+	ImportRetryInterval_default = time.Second //导入失败重试间隔
+)
+
+/*
+|request|object | 是  | 无 | 代理请求参数|
+|request.body|string | 否  | 无 | 代理请求body 体（每批数据写入 rowsPath 路径，rowsPath 为空时 body 为数据数组） |
+*/
+
+// ImportProxyRequest 导入代理请求参数
+type ImportProxyRequest struct {
+	RequestDTO      httpraw.RequestDTO                            `json:"request"`
+	RowsPath        string                                        `json:"rowsPath"`      //每批数据在请求体中的路径，例如：data.list,为空时请求体为数据数组
+	BatchSize       int                                           `json:"batchSize"`     //每批提交行数,默认：100
+	MaxRetries      int                                           `json:"maxRetries"`    //每批失败重试次数,默认不重试
+	RetryInterval   time.Duration                                 `json:"retryInterval"` //重试间隔,默认：1s
+	MiddlewareFuncs apihttpprotocol.MiddlewareFuncsRequestMessage `json:"-"`             // 请求中间件函数列表，一般可以使用动态脚本生成
+	RequestFormatFn defined.RequestFormatFn                       `json:"-"`             //请求格式化函数，例如：func(request httpraw.RequestDTO)(newRequest httpraw.RequestDTO,err error){ return request,nil}
+	RecordsFormatFn defined.RecordsFormatFn                       `json:"-"`             //提交前记录处理函数(校验之后执行),返回0条不提交该行,最多返回1条(导入结果按行对应)
+	SendFn          ProxySendFn                                   `json:"-"`             //自定义发送函数,为空时使用 http 客户端
+}
+
+func (r ImportProxyRequest) getBatchSize() int {
+	if r.BatchSize <= 0 {
+		return ImportBatchSize_default
+	}
+	return r.BatchSize
+}
+
+func (r ImportProxyRequest) getRetryInterval() time.Duration {
+	if r.RetryInterval <= 0 {
+		return ImportRetryInterval_default
+	}
+	return r.RetryInterval
+}
+
+// ImportProxyResponse 导入代理响应参数
+type ImportProxyResponse struct {
+	BusinessCodePath  string                                         `json:"businessCodePath"`  //业务成功标识路径，例如：$.code
+	BusinessOkCode    string                                         `json:"businessOkCode"`    //业务成功标识值，例如：0
+	FailedRowsPath    string                                         `json:"failedRowsPath"`    //批次中失败行列表路径(业务成功时部分行失败)，例如：data.failed
+	FailedIndexPath   string                                         `json:"failedIndexPath"`   //失败行在批次中的序号路径(相对 FailedRowsPath 下每条数据,从0开始)，例如：index
+	FailedMessagePath string                                         `json:"failedMessagePath"` //失败行错误信息路径(相对 FailedRowsPath 下每条数据)，例如：message
+	MiddlewareFuncs   apihttpprotocol.MiddlewareFuncsResponseMessage `json:"-"`                 // 请求中间件函数列表，一般可以使用动态脚本生成
+}
+
+// ImportApiIn 导入参数
+type ImportApiIn struct {
+	File          io.Reader           `json:"-" validate:"required"`            //上传文件(xlsx、xls、csv、tsv)
+	Filename      string              `json:"filename"`                         //上传文件名,用于识别文件格式(文件头无法识别时按扩展名)
+	Sheet         string              `json:"sheet"`                            //表单名称,默认第一个表单
+	FieldMetas    defined.FieldMetas  `json:"fieldMetas" validate:"required"`   //字段元数据,按标题匹配列,Validate 规则校验失败的行不提交
+	HeaderOptions HeaderOptions       `json:"headerOptions"`                    //标题行选项
+	UnmergeCell   bool                `json:"unmergeCell"`                      //展开合并单元格,值填充到每个单元格
+	ProxyRequest  ImportProxyRequest  `json:"proxyRequest" validate:"required"` //请求数据参数
+	ProxyResponse ImportProxyResponse `json:"proxyResponse"`                    //响应数据参数
+	ProgressFn    ImportProgressFn    `json:"-"`                                //每批处理完成后回调当前导入结果(累计),可用于保存导入进度,返回错误时终止导入
+}
+
+// ImportProgressFn 导入进度回调函数，例如：func(result ImportResult) error { return repo.UpdateProgress(result.TaskUpdateProgressIn(taskId)) }
+type ImportProgressFn func(result ImportResult) (err error)
+
+// ImportRowResult 行导入结果
+type ImportRowResult struct {
+	Row   int    `json:"row"` //行号(从1开始)
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResult 导入结果,有失败行时 ErrorFile 为标注错误的文件副本
+type ImportResult struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+	ErrorFile *bytes.Buffer     `json:"-"`
+}
+
+func (r *ImportResult) add(row int, err error) {
+	r.Total++
+	result := ImportRowResult{Row: row, Ok: err == nil}
+	if err != nil {
+		r.Failed++
+		result.Error = err.Error()
+	} else {
+		r.Succeeded++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// TaskUpdateStatusIn 生成导入任务状态更新参数(全部行处理完成即为成功,失败行数见 Failed),err 为 ImportApi 返回的错误,url 为结果文件下载地址
+func (r ImportResult) TaskUpdateStatusIn(taskId int, url string, err error) (in repository.ImportTaskRepositoryUpdateStatusIn) {
+	in = repository.ImportTaskRepositoryUpdateStatusIn{
+		Id:        taskId,
+		Status:    repository.Task_status_success,
+		Total:     r.Total,
+		Succeeded: r.Succeeded,
+		Failed:    r.Failed,
+		Url:       url,
+	}
+	if err != nil {
+		in.Status = repository.Task_status_failed
+		in.Remark = err.Error()
+	}
+	return in
+}
+
+// TaskUpdateProgressIn 生成导入任务进度更新参数
+func (r ImportResult) TaskUpdateProgressIn(taskId int) (in repository.ImportTaskRepositoryUpdateProgressIn) {
+	in = repository.ImportTaskRepositoryUpdateProgressIn{
+		Id:        taskId,
+		Total:     r.Total,
+		Succeeded: r.Succeeded,
+		Failed:    r.Failed,
+	}
+	return in
+}
+
+// ImportApi 读取上传文件,按字段元数据匹配列、校验后分批提交到代理接口，可直接对接http请求;
+// 单批提交失败(请求错误、业务标识不符)时按 MaxRetries 重试,仍失败则该批全部行记为失败,继续提交下一批;
+// ctx 取消时等待当前请求完成后停止(不再重试、不再提交下一批),返回 ctx 错误,已完成请求的行可能已提交
+func ImportApi(ctx context.Context, in ImportApiIn) (result ImportResult, err error) {
+	err = validator.New().Struct(in)
+	if err != nil {
+		return result, err
+	}
+	f, err := OpenUpload(in.Filename, in.File)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+	sheet := in.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	reader := NewExcelReader()
+	if in.UnmergeCell {
+		err = reader.UnmergeCell(f, sheet)
+		if err != nil {
+			return result, err
+		}
+	}
+	fieldMap, rowIndex, err := reader.MatchHeader(f, sheet, in.FieldMetas, in.HeaderOptions)
+	if err != nil {
+		return result, err
+	}
+	headerRow := rowIndex - 1
+	batchSize := in.ProxyRequest.getBatchSize()
+	var failedErrs CellErrors
+	err = reader.ReadStream(ctx, f, sheet, fieldMap, rowIndex, batchSize, func(records []map[string]string) (err error) {
+		startRow := rowIndex
+		rowIndex += len(records)
+		rowErrs := make(map[int][]CellError) // 行号=>校验错误
+		var cellErrs CellErrors
+		err = ValidateRecords(records, startRow, in.FieldMetas, fieldMap)
+		if err != nil && !errors.As(err, &cellErrs) {
+			return err
+		}
+		for _, cellErr := range cellErrs {
+			rowErrs[cellErr.Row] = append(rowErrs[cellErr.Row], cellErr)
+		}
+		rows := make([]int, 0, len(records))
+		batch := make([]map[string]any, 0, len(records))
+		for i, record := range records {
+			row := startRow + i
+			if isEmptyRecord(record) {
+				continue
+			}
+			if errs, ok := rowErrs[row]; ok {
+				failedErrs = append(failedErrs, errs...)
+				result.add(row, CellErrors(errs).message())
+				continue
+			}
+			if in.ProxyRequest.RecordsFormatFn != nil {
+				records, err := in.ProxyRequest.RecordsFormatFn(record)
+				if err == nil && len(records) > 1 {
+					err = errors.Errorf("recordsFormatFn returned %d records, import accepts at most one record per row", len(records))
+				}
+				if err != nil {
+					result.add(row, err)
+					failedErrs = append(failedErrs, CellError{Row: row, Err: err})
+					continue
+				}
+				if len(records) == 0 || records[0] == nil { // 返回0条视为不提交该行
+					continue
+				}
+				record = records[0]
+			}
+			rows = append(rows, row)
+			batch = append(batch, makeImportRow(in.FieldMetas, record))
+		}
+		if len(batch) > 0 {
+			rowResults, err := importBatch(ctx, in.ProxyRequest, in.ProxyResponse, batch)
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				rowResults = make([]error, len(batch))
+				for i := range rowResults {
+					rowResults[i] = err
+				}
+			}
+			for i, row := range rows {
+				result.add(row, rowResults[i])
+				if rowResults[i] != nil {
+					failedErrs = append(failedErrs, CellError{Row: row, Err: rowResults[i]})
+				}
+			}
+		}
+		if in.ProgressFn != nil {
+			err = in.ProgressFn(result)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if len(failedErrs) > 0 {
+		result.ErrorFile, err = reader.AnnotateErrors(f, sheet, headerRow, failedErrs)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// MakeImportApiInArgs 生成导入配置参数
+type MakeImportApiInArgs struct {
+	ConfigKey string    `json:"configKey"` //导入配置键
+	CreatorId string    `json:"creatorId"` //创建者ID，例如：1
+	File      io.Reader `json:"-"`         //上传文件
+	Filename  string    `json:"filename"`  //上传文件名
+	Sheet     string    `json:"sheet"`     //表单名称,默认第一个表单
+	Request   Request   `json:"request"`   //请求数据参数,body 可在请求模板中引用,例如：{{shopId}}
+	response  Response  `json:"-"`         //响应数据参数,只用于收集中间件,不对外开放
+}
+
+// MakeImportApiIn 生成导入配置信息
+func MakeImportApiIn(in MakeImportApiInArgs, config repository.ImportConfigModel) (importApiIn ImportApiIn, err error) {
+	var requestBody any
+	if in.Request.Body != nil {
+		err = json.Unmarshal(in.Request.Body, &requestBody)
+		if err != nil {
+			return importApiIn, err
+		}
+	}
+	data := map[string]any{
+		"creatorId": in.CreatorId,
+		"body":      string(in.Request.Body),
+	}
+	fieldMetas, err := config.ParseFieldMetas()
+	if err != nil {
+		return importApiIn, err
+	}
+	dynamicFn, err := config.ParseDynamicScript()
+	if err != nil {
+		return importApiIn, err
+	}
+	if dynamicFn.RequestFormatFn != nil {
+		in.Request.RequestFormatFn = dynamicFn.RequestFormatFn
+	}
+	reqDTO, err := config.RenderRequestDTO(data, requestBody)
+	if err != nil {
+		return importApiIn, err
+	}
+	maps.Copy(reqDTO.Headers, in.Request.Headers)
+	importApiIn = ImportApiIn{
+		File:          in.File,
+		Filename:      in.Filename,
+		Sheet:         in.Sheet,
+		FieldMetas:    fieldMetas,
+		HeaderOptions: HeaderOptions{HeaderRow: config.HeaderRow},
+		UnmergeCell:   config.IsUnmergeCell(),
+		ProxyRequest: ImportProxyRequest{
+			RequestDTO:      *reqDTO,
+			RowsPath:        config.RowsPath,
+			BatchSize:       config.BatchSize,
+			MaxRetries:      config.MaxRetries,
+			MiddlewareFuncs: in.Request.MiddlewareFuncs,
+			RequestFormatFn: in.Request.RequestFormatFn,
+			RecordsFormatFn: dynamicFn.RecordsFormatFn, // 与导出一致,js recordFormatFn 返回null 不提交该行
+		}, //请求数据参数
+		ProxyResponse: ImportProxyResponse{
+			BusinessCodePath:  config.BusinessCodePath,
+			BusinessOkCode:    config.BusinessOkCode,
+			FailedRowsPath:    config.FailedRowsPath,
+			FailedIndexPath:   config.FailedIndexPath,
+			FailedMessagePath: config.FailedMessagePath,
+			MiddlewareFuncs:   in.response.MiddlewareFuncs,
+		}, //响应数据参数
+	}
+	return importApiIn, nil
+}
+
+// message 合并错误信息(不含单元格坐标),用于行导入结果
+func (errs CellErrors) message() error {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Err.Error())
+	}
+	return errors.New(strings.Join(msgs, ";"))
+}
+
+// jsonNumberExp json 数字格式,NaN、Inf、十六进制等 strconv.ParseFloat 可解析的格式 json 不支持
+var jsonNumberExp = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// makeImportRow 生成提交数据,数字类型字段为 json 数字格式时按数字提交(保留原始文本精度),其余按字符串提交
+func makeImportRow(fieldMetas defined.FieldMetas, record map[string]string) (row map[string]any) {
+	row = make(map[string]any, len(record))
+	for name, value := range record {
+		row[name] = value
+	}
+	for _, fieldMeta := range fieldMetas {
+		value, ok := record[fieldMeta.Name]
+		if !ok || fieldMeta.Type != defined.FieldType_number {
+			continue
+		}
+		number := strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+		if jsonNumberExp.MatchString(number) {
+			row[fieldMeta.Name] = json.Number(number)
+		}
+	}
+	return row
+}
+
+// importBatch 提交一批数据,失败时重试;返回每行结果(nil 表示成功),err 不为空表示整批失败
+func importBatch(ctx context.Context, proxyReq ImportProxyRequest, proxyRsp ImportProxyResponse, batch []map[string]any) (rowResults []error, err error) {
+	requestDTO, err := makeImportRequestDTO(proxyReq, batch)
+	if err != nil {
+		return nil, err
+	}
+	sendFn := proxyReq.SendFn
+	if sendFn == nil {
+		sendFn = defaultProxySendFn(proxyReq.MiddlewareFuncs, proxyRsp.MiddlewareFuncs)
+	}
+	for attempt := 0; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil { // 已取消时不再发送(含重试)
+			return nil, ctxErr
+		}
+		var resp json.RawMessage
+		var curlCommand string
+		resp, curlCommand, err = sendFn(ctx, requestDTO)
+		if err == nil {
+			err = checkBusinessCode(resp, proxyRsp.BusinessCodePath, proxyRsp.BusinessOkCode, requestDTO.URL, curlCommand)
+		}
+		if err == nil {
+			return makeImportRowResults(proxyRsp, resp, len(batch)), nil
+		}
+		if attempt >= proxyReq.MaxRetries {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(proxyReq.getRetryInterval()):
+		}
+	}
+}
+
+// makeImportRequestDTO 将一批数据写入请求体 RowsPath 路径
+func makeImportRequestDTO(proxyReq ImportProxyRequest, batch []map[string]any) (requestDTO httpraw.RequestDTO, err error) {
+	requestDTO = proxyReq.RequestDTO
+	rows, err := json.Marshal(batch)
+	if err != nil {
+		return requestDTO, err
+	}
+	if proxyReq.RowsPath == "" {
+		requestDTO.Body = string(rows)
+	} else {
+		body := requestDTO.Body
+		if strings.TrimSpace(body) == "" {
+			body = "{}"
+		}
+		requestDTO.Body, err = sjson.SetRaw(body, proxyReq.RowsPath, string(rows))
+		if err != nil {
+			err = errors.WithMessagef(err, "rowsPath:%s", proxyReq.RowsPath)
+			return requestDTO, err
+		}
+	}
+	if proxyReq.RequestFormatFn != nil {
+		requestDTO, err = proxyReq.RequestFormatFn(requestDTO)
+		if err != nil {
+			return requestDTO, err
+		}
+	}
+	return requestDTO, nil
+}
+
+// makeImportRowResults 按响应中的失败行列表生成每行结果,未配置 FailedRowsPath 时全部成功
+func makeImportRowResults(proxyRsp ImportProxyResponse, resp json.RawMessage, size int) (rowResults []error) {
+	rowResults = make([]error, size)
+	if proxyRsp.FailedRowsPath == "" {
+		return rowResults
+	}
+	for _, failed := range gjson.GetBytes(resp, proxyRsp.FailedRowsPath).Array() {
+		index := int(failed.Get(proxyRsp.FailedIndexPath).Int())
+		if proxyRsp.FailedIndexPath == "" {
+			index = int(failed.Int())
+		}
+		if index < 0 || index >= size {
+			continue
+		}
+		msg := "导入失败"
+		if proxyRsp.FailedMessagePath != "" {
+			if message := failed.Get(proxyRsp.FailedMessagePath).String(); message != "" {
+				msg = message
+			}
+		}
+		rowResults[index] = errors.New(msg)
+	}
+	return rowResults
+}
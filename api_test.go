@@ -0,0 +1,266 @@
+package excelrw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/suifengpiao14/excelrw/repository"
+	"github.com/suifengpiao14/httpraw"
+	"github.com/tidwall/gjson"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestImportApi(t *testing.T) {
+	fd := excelize.NewFile()
+	defer fd.Close()
+	sheet := fd.GetSheetName(0)
+	require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"金额", "名称"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A2", &[]any{"1,000", "a"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A3", &[]any{"x", "b"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A4", &[]any{"3", "c"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A5", &[]any{"4", "d"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A6", &[]any{"5", "e"}))
+	file, err := fd.WriteToBuffer()
+	require.NoError(t, err)
+
+	bodies := make([]string, 0)
+	calls := 0
+	progress := make([]repository.ImportTaskRepositoryUpdateProgressIn, 0)
+	in := excelrw.ImportApiIn{
+		File: bytes.NewReader(file.Bytes()),
+		FieldMetas: defined.FieldMetas{
+			{Name: "amount", Title: "金额", Type: defined.FieldType_number, Validate: "gte=0"},
+			{Name: "name", Title: "名称", Validate: "required"},
+		},
+		ProxyRequest: excelrw.ImportProxyRequest{
+			RequestDTO: httpraw.RequestDTO{Method: "POST", URL: "http://localhost/import", Body: `{"data":{"source":"excel"}}`},
+			RowsPath:   "data.list",
+			BatchSize:  2,
+			MaxRetries: 1,
+			SendFn: func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+				calls++
+				if calls == 1 {
+					return json.RawMessage(`{"code":"500"}`), "", nil // 第一次失败重试
+				}
+				bodies = append(bodies, requestDTO.Body)
+				if gjson.Get(requestDTO.Body, "data.list.0.name").String() == "e" {
+					return json.RawMessage(`{"code":"500"}`), "", nil
+				}
+				return json.RawMessage(`{"code":"0","data":{"failed":[{"index":1,"message":"名称重复"}]}}`), "", nil
+			},
+			RetryInterval: 1,
+		},
+		ProgressFn: func(result excelrw.ImportResult) (err error) {
+			progress = append(progress, result.TaskUpdateProgressIn(1))
+			return nil
+		},
+		ProxyResponse: excelrw.ImportProxyResponse{
+			BusinessCodePath:  "code",
+			BusinessOkCode:    "0",
+			FailedRowsPath:    "data.failed",
+			FailedIndexPath:   "index",
+			FailedMessagePath: "message",
+		},
+	}
+	result, err := excelrw.ImportApi(context.Background(), in)
+	require.NoError(t, err)
+	require.Equal(t, 5, result.Total)
+	require.Equal(t, 2, result.Succeeded)
+	require.Equal(t, 3, result.Failed)
+	require.Equal(t, `{"data":{"source":"excel","list":[{"amount":1000,"name":"a"}]}}`, bodies[0])
+
+	rows := make(map[int]excelrw.ImportRowResult)
+	for _, row := range result.Rows {
+		rows[row.Row] = row
+	}
+	require.True(t, rows[2].Ok)
+	require.True(t, rows[4].Ok)
+	require.Equal(t, "金额必须是数字", rows[3].Error)
+	require.Equal(t, "名称重复", rows[5].Error)
+	require.Contains(t, rows[6].Error, "actualBusinessCode")
+	require.Len(t, bodies, 4)                                           // 第2行重试成功、第4~5行各一次,第6行失败重试一次
+	require.Equal(t, []repository.ImportTaskRepositoryUpdateProgressIn{ // 每批处理完成后回调累计结果
+		{Id: 1, Total: 2, Succeeded: 1, Failed: 1},
+		{Id: 1, Total: 4, Succeeded: 2, Failed: 2},
+		{Id: 1, Total: 5, Succeeded: 2, Failed: 3},
+	}, progress)
+
+	annotated, err := excelize.OpenReader(result.ErrorFile)
+	require.NoError(t, err)
+	defer annotated.Close()
+	msg, err := annotated.GetCellValue(sheet, "C5")
+	require.NoError(t, err)
+	require.Equal(t, "名称重复", msg)
+}
+
+func TestImportApiNumber(t *testing.T) {
+	fd := excelize.NewFile()
+	defer fd.Close()
+	sheet := fd.GetSheetName(0)
+	require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"金额"}))
+	for i, value := range []string{"NaN", "0x1p3", "1,000", "-1.5e3"} {
+		require.NoError(t, fd.SetCellStr(sheet, fmt.Sprintf("A%d", i+2), value))
+	}
+	file, err := fd.WriteToBuffer()
+	require.NoError(t, err)
+
+	var body string
+	in := excelrw.ImportApiIn{
+		File:       bytes.NewReader(file.Bytes()),
+		FieldMetas: defined.FieldMetas{{Name: "amount", Title: "金额", Type: defined.FieldType_number}},
+		ProxyRequest: excelrw.ImportProxyRequest{
+			RequestDTO: httpraw.RequestDTO{Method: "POST", URL: "http://localhost/import", Body: `{}`},
+			RowsPath:   "list",
+			SendFn: func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+				body = requestDTO.Body
+				return json.RawMessage(`{}`), "", nil
+			},
+		},
+	}
+	result, err := excelrw.ImportApi(context.Background(), in)
+	require.NoError(t, err)
+	require.Equal(t, 4, result.Succeeded)
+	require.True(t, json.Valid([]byte(body)))
+	require.Equal(t, `{"list":[{"amount":"NaN"},{"amount":"0x1p3"},{"amount":1000},{"amount":-1.5e3}]}`, body) // 非 json 数字格式按字符串提交
+}
+
+func TestImportApiCancel(t *testing.T) {
+	fd := excelize.NewFile()
+	defer fd.Close()
+	sheet := fd.GetSheetName(0)
+	require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"名称"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A2", &[]any{"a"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A3", &[]any{"b"}))
+	file, err := fd.WriteToBuffer()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	in := excelrw.ImportApiIn{
+		File:       bytes.NewReader(file.Bytes()),
+		FieldMetas: defined.FieldMetas{{Name: "name", Title: "名称"}},
+		ProxyRequest: excelrw.ImportProxyRequest{
+			RequestDTO: httpraw.RequestDTO{Method: "POST", URL: "http://localhost/import", Body: `{}`},
+			RowsPath:   "list",
+			BatchSize:  1,
+			MaxRetries: 3,
+			SendFn: func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+				calls++
+				cancel() // 请求过程中取消
+				return json.RawMessage(`{"code":"500"}`), "", nil
+			},
+			RetryInterval: 1,
+		},
+		ProxyResponse: excelrw.ImportProxyResponse{BusinessCodePath: "code", BusinessOkCode: "0"},
+	}
+	_, err = excelrw.ImportApi(ctx, in)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, calls) // 取消后不再重试、不再提交下一批
+}
+
+func TestExportApiRecordsFormatFn(t *testing.T) {
+	pages := map[int64]string{
+		1: `{"code":"0","data":[{"name":"a"},{"name":"b","deleted":true}]}`,
+		2: `{"code":"0","data":[{"name":"c","deleted":true}]}`, // 整页被过滤
+		3: `{"code":"0","data":[{"name":"d"}]}`,
+	}
+	filename := filepath.Join(t.TempDir(), "export.xlsx")
+	in := excelrw.ExportApiIn{
+		ProxyRquest: excelrw.ProxyRquest{
+			RequestDTO:    httpraw.RequestDTO{Method: "POST", URL: "http://localhost/list", Body: `{"pageIndex":1}`},
+			PageIndexPath: "pageIndex",
+			SendFn: func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+				page, ok := pages[gjson.Get(requestDTO.Body, "pageIndex").Int()]
+				if !ok {
+					page = `{"code":"0","data":[]}`
+				}
+				return json.RawMessage(page), "", nil
+			},
+		},
+		ProxyResponse: excelrw.ProxyResponse{
+			DataPath:         "data",
+			BusinessCodePath: "code",
+			BusinessOkCode:   "0",
+			RecordsFormatFn: func(record map[string]string) (newRecords []map[string]string, err error) {
+				if record["deleted"] == "true" {
+					return nil, nil
+				}
+				return []map[string]string{record}, nil
+			},
+		},
+		Settings: excelrw.Settings{
+			Filename: filename,
+			FieldMetas: defined.FieldMetas{
+				{Name: "_rowNumber", Title: "序号"},
+				{Name: "name", Title: "名称"},
+				{Name: "deleted", Title: "删除"},
+			},
+		},
+	}
+	errChan, err := excelrw.ExportApi(in)
+	require.NoError(t, err)
+	require.NoError(t, <-errChan)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	rows, err := fd.GetRows(excelrw.SheetDefault)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"序号", "名称", "删除"}, {"1", "a"}, {"2", "d"}}, rows)
+}
+
+func TestExportSheetsApi(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sheets.xlsx")
+	in := excelrw.ExportSheetsApiIn{
+		Filename: filename,
+		Sheets: []excelrw.ExportSheet{
+			{
+				Name: "订单",
+				ProxyRquest: excelrw.ProxyRquest{
+					RequestDTO:    httpraw.RequestDTO{Method: "POST", URL: "http://localhost/orders", Body: `{"pageIndex":1}`},
+					PageIndexPath: "pageIndex",
+					SendFn: func(ctx context.Context, requestDTO httpraw.RequestDTO) (resp json.RawMessage, curlCommand string, err error) {
+						if gjson.Get(requestDTO.Body, "pageIndex").Int() > 1 {
+							return json.RawMessage(`{"data":[]}`), "", nil
+						}
+						return json.RawMessage(`{"data":[{"id":"1"},{"id":"2"}]}`), "", nil
+					},
+				},
+				ProxyResponse: excelrw.ProxyResponse{DataPath: "data"},
+				FieldMetas:    defined.FieldMetas{{Name: "id", Title: "ID"}},
+			},
+			{
+				Name: "失败",
+				ProxyRquest: excelrw.ProxyRquest{
+					RequestDTO:    httpraw.RequestDTO{Method: "POST", URL: "http://localhost/refunds", Body: `{}`},
+					PageIndexPath: "pageIndex", // 请求体中不存在,生成数据获取器失败
+				},
+				ProxyResponse: excelrw.ProxyResponse{DataPath: "data"},
+				FieldMetas:    defined.FieldMetas{{Name: "id", Title: "ID"}},
+			},
+		},
+	}
+	errChan, err := excelrw.ExportSheetsApi(in)
+	require.NoError(t, err)
+	err = <-errChan
+	var sheetErrs excelrw.SheetErrors
+	require.ErrorAs(t, err, &sheetErrs)
+	require.Len(t, sheetErrs, 1)
+	require.Equal(t, "失败", sheetErrs[0].Sheet)
+	require.ErrorContains(t, sheetErrs[0], "pageIndexPath:pageIndex")
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	rows, err := fd.GetRows("订单")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"ID"}, {"1"}, {"2"}}, rows)
+}
@@ -0,0 +1,130 @@
+package defined
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Aggregate_sum      = "sum"
+	Aggregate_count    = "count"
+	Aggregate_avg      = "avg"
+	Aggregate_min      = "min"
+	Aggregate_max      = "max"
+	Aggregate_distinct = "distinct" // 去重计数
+)
+
+var ErrorAggregateNotSupported = errors.New("aggregate not supported")
+
+// Aggregator 汇总计算器,逐个累加单元格值(流式写入时无需保留全部数据)
+type Aggregator struct {
+	fn       string
+	count    int // 非空值个数
+	numCount int // 数字个数
+	sum      float64
+	min      float64
+	max      float64
+	distinct map[string]struct{}
+}
+
+func NewAggregator(fn string) (aggregator *Aggregator, err error) {
+	switch fn {
+	case Aggregate_sum, Aggregate_count, Aggregate_avg, Aggregate_min, Aggregate_max, Aggregate_distinct:
+	default:
+		err = errors.WithMessagef(ErrorAggregateNotSupported, "aggregate:%s", fn)
+		return nil, err
+	}
+	aggregator = &Aggregator{
+		fn:       fn,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+		distinct: make(map[string]struct{}),
+	}
+	return aggregator, nil
+}
+
+// Add 累加单元格值,空值忽略,sum/avg/min/max 只计算可解析为数字的值(兼容千分位)
+func (a *Aggregator) Add(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	a.count++
+	if a.fn == Aggregate_distinct {
+		a.distinct[value] = struct{}{}
+		return
+	}
+	number, ok := ParseNumber(value)
+	if !ok {
+		return
+	}
+	a.numCount++
+	a.sum += number
+	a.min = math.Min(a.min, number)
+	a.max = math.Max(a.max, number)
+}
+
+// ParseNumber 解析数字(兼容千分位),汇总计算与汇总列单元格写入使用相同规则
+func ParseNumber(value string) (number float64, ok bool) {
+	number, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", ""), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+// IsNumericAggregate 是否为按数字计算的汇总方式(sum、avg、min、max),使用公式时数据单元格需写入数字
+func IsNumericAggregate(aggregate string) bool {
+	switch aggregate {
+	case Aggregate_sum, Aggregate_avg, Aggregate_min, Aggregate_max:
+		return true
+	}
+	return false
+}
+
+// Result 汇总结果
+func (a *Aggregator) Result() float64 {
+	switch a.fn {
+	case Aggregate_count:
+		return float64(a.count)
+	case Aggregate_distinct:
+		return float64(len(a.distinct))
+	case Aggregate_sum:
+		return a.sum
+	}
+	if a.numCount == 0 {
+		return 0
+	}
+	switch a.fn {
+	case Aggregate_avg:
+		return a.sum / float64(a.numCount)
+	case Aggregate_min:
+		return a.min
+	case Aggregate_max:
+		return a.max
+	}
+	return 0
+}
+
+// Formula 生成汇总公式,rangeRef 为数据区域，例如：B2:B100
+func (a *Aggregator) Formula(rangeRef string) string {
+	switch a.fn {
+	case Aggregate_sum:
+		return fmt.Sprintf("SUM(%s)", rangeRef)
+	case Aggregate_count:
+		return fmt.Sprintf("COUNTA(%s)", rangeRef)
+	case Aggregate_avg:
+		return fmt.Sprintf("AVERAGE(%s)", rangeRef)
+	case Aggregate_min:
+		return fmt.Sprintf("MIN(%s)", rangeRef)
+	case Aggregate_max:
+		return fmt.Sprintf("MAX(%s)", rangeRef)
+	case Aggregate_distinct:
+		return fmt.Sprintf(`SUMPRODUCT((%s<>"")/COUNTIF(%s,%s&""))`, rangeRef, rangeRef, rangeRef)
+	}
+	return ""
+}
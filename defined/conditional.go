@@ -0,0 +1,78 @@
+package defined
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	Conditional_cell       = "cell"       // 单元格值比较
+	Conditional_text       = "text"       // 文本匹配
+	Conditional_colorScale = "colorScale" // 色阶
+	Conditional_dataBar    = "dataBar"    // 数据条
+	Conditional_formula    = "formula"    // 公式,可引用其它列(用于整行高亮)
+)
+
+var ErrorConditionalNotSupported = errors.New("conditional format not supported")
+
+// ConditionalFormat 条件格式规则,写入完成后应用到列的数据区域
+type ConditionalFormat struct {
+	Type     string     `json:"type"`               // 规则类型：cell、text、colorScale、dataBar、formula
+	Field    string     `json:"field,omitempty"`    // 应用的列(表单级规则使用),为空时 formula 规则应用到整行
+	Operator string     `json:"operator,omitempty"` // cell：>、>=、<、<=、=、!=、between、notBetween; text：contains(默认)、notContains、beginsWith、endsWith
+	Value    string     `json:"value,omitempty"`    // 比较值,cell 规则为数字或公式(文本需加双引号)，例如：0、"已过期"
+	MinValue string     `json:"minValue,omitempty"` // between、notBetween 最小值
+	MaxValue string     `json:"maxValue,omitempty"` // between、notBetween 最大值
+	Formula  string     `json:"formula,omitempty"`  // formula 规则公式,{字段名} 引用当前行该列单元格，例如：{expireDate}<TODAY()
+	Style    *CellStyle `json:"style,omitempty"`    // 满足条件时的样式(cell、text、formula)，例如：{"font":{"color":"#FF0000"}}
+	MinColor string     `json:"minColor,omitempty"` // 色阶最小值颜色,默认：#F8696B
+	MidColor string     `json:"midColor,omitempty"` // 色阶中间值颜色,为空时使用双色色阶
+	MaxColor string     `json:"maxColor,omitempty"` // 色阶最大值颜色,默认：#63BE7B
+	BarColor string     `json:"barColor,omitempty"` // 数据条颜色,默认：#638EC6
+}
+
+const (
+	ConditionalMinColor_default = "#F8696B"
+	ConditionalMaxColor_default = "#63BE7B"
+	ConditionalBarColor_default = "#638EC6"
+)
+
+var conditionalCellOperators = map[string]string{
+	">":          ">",
+	">=":         ">=",
+	"<":          "<",
+	"<=":         "<=",
+	"=":          "==",
+	"==":         "==",
+	"!=":         "!=",
+	"<>":         "!=",
+	"between":    "between",
+	"notBetween": "not between",
+}
+
+var conditionalTextOperators = map[string]string{
+	"":            "containing",
+	"contains":    "containing",
+	"notContains": "not containing",
+	"beginsWith":  "begins with",
+	"endsWith":    "ends with",
+}
+
+// Criteria 转换为 excelize 条件格式的 Criteria
+func (c ConditionalFormat) Criteria() (criteria string, err error) {
+	var ok bool
+	switch c.Type {
+	case Conditional_cell:
+		criteria, ok = conditionalCellOperators[c.Operator]
+	case Conditional_text:
+		criteria, ok = conditionalTextOperators[c.Operator]
+	case Conditional_colorScale, Conditional_dataBar:
+		criteria, ok = "=", true
+	case Conditional_formula:
+		criteria, ok = c.Formula, c.Formula != ""
+	}
+	if !ok {
+		err = errors.WithMessagef(ErrorConditionalNotSupported, "type:%s,operator:%s", c.Type, c.Operator)
+		return "", err
+	}
+	return criteria, nil
+}
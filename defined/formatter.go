@@ -0,0 +1,242 @@
+package defined
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// FormatterFn 列值格式化函数,args 为格式化参数，例如：date:2006-01-02 => args=["2006-01-02"],mask:3,4 => args=["3","4"]
+type FormatterFn func(value string, args ...string) (newValue string, err error)
+
+var (
+	formatters     = map[string]FormatterFn{}
+	formattersLock sync.RWMutex
+)
+
+// RegisterFormatter 注册格式化函数,同名覆盖(可覆盖内置格式化函数),返回恢复函数(恢复注册前的格式化函数,用于测试等临时注册)
+func RegisterFormatter(name string, fn FormatterFn) (restore func()) {
+	formattersLock.Lock()
+	defer formattersLock.Unlock()
+	old, exists := formatters[name]
+	formatters[name] = fn
+	return func() {
+		formattersLock.Lock()
+		defer formattersLock.Unlock()
+		if exists {
+			formatters[name] = old
+			return
+		}
+		delete(formatters, name)
+	}
+}
+
+func GetFormatter(name string) (fn FormatterFn, ok bool) {
+	formattersLock.RLock()
+	defer formattersLock.RUnlock()
+	fn, ok = formatters[name]
+	return fn, ok
+}
+
+var ErrorFormatterNotFound = errors.New("formatter not found")
+
+// Format 按管道依次格式化，例如：unixtime|date:2006-01-02、money、mask:3,4
+func Format(value string, format string) (newValue string, err error) {
+	newValue = value
+	for _, pipe := range strings.Split(format, "|") {
+		pipe = strings.TrimSpace(pipe)
+		if pipe == "" {
+			continue
+		}
+		name, argStr, _ := strings.Cut(pipe, ":")
+		fn, ok := GetFormatter(name)
+		if !ok {
+			err = errors.WithMessagef(ErrorFormatterNotFound, "name:%s", name)
+			return value, err
+		}
+		args := make([]string, 0)
+		if argStr != "" {
+			args = strings.Split(argStr, ",")
+		}
+		newValue, err = fn(newValue, args...)
+		if err != nil {
+			err = errors.WithMessagef(err, "formatter:%s,value:%s", pipe, value)
+			return value, err
+		}
+	}
+	return newValue, nil
+}
+
+const DatetimeLayout = "2006-01-02 15:04:05"
+
+// DateLayouts date 格式化函数解析输入值时依次尝试的格式
+var DateLayouts = []string{
+	DatetimeLayout,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102150405",
+	"20060102",
+}
+
+func init() {
+	RegisterFormatter("unixtime", formatUnixtime)
+	RegisterFormatter("date", formatDate)
+	RegisterFormatter("div", formatDiv)
+	RegisterFormatter("mul", formatMul)
+	RegisterFormatter("fixed", formatFixed)
+	RegisterFormatter("money", formatMoney)
+	RegisterFormatter("mask", formatMask)
+	RegisterFormatter("truncate", formatTruncate)
+	RegisterFormatter("default", formatDefault)
+	RegisterFormatter("trim", func(value string, args ...string) (string, error) { return strings.TrimSpace(value), nil })
+	RegisterFormatter("upper", func(value string, args ...string) (string, error) { return strings.ToUpper(value), nil })
+	RegisterFormatter("lower", func(value string, args ...string) (string, error) { return strings.ToLower(value), nil })
+}
+
+// formatUnixtime 时间戳(秒,超过13位按毫秒处理)转换为时间，例如：unixtime、unixtime:2006-01-02
+func formatUnixtime(value string, args ...string) (string, error) {
+	if value == "" || value == "0" {
+		return "", nil
+	}
+	timestamp, err := cast.ToInt64E(value)
+	if err != nil {
+		return "", err
+	}
+	t := time.Unix(timestamp, 0)
+	if timestamp > 1e12 {
+		t = time.UnixMilli(timestamp)
+	}
+	layout := DatetimeLayout
+	if len(args) > 0 {
+		layout = strings.Join(args, ",")
+	}
+	return t.Local().Format(layout), nil
+}
+
+// formatDate 日期重新格式化，例如：date:2006-01-02
+func formatDate(value string, args ...string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if len(args) == 0 {
+		return "", errors.New("date layout required,example: date:2006-01-02")
+	}
+	layout := strings.Join(args, ",")
+	for _, inputLayout := range DateLayouts {
+		t, err := time.ParseInLocation(inputLayout, value, time.Local)
+		if err == nil {
+			return t.Format(layout), nil
+		}
+	}
+	return "", errors.Errorf("unsupported date value:%s", value)
+}
+
+func formatDiv(value string, args ...string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if len(args) == 0 {
+		return "", errors.New("divisor required,example: div:100")
+	}
+	divisor := cast.ToFloat64(args[0])
+	if divisor == 0 {
+		return "", errors.New("divisor can not be zero")
+	}
+	number, err := cast.ToFloat64E(value)
+	if err != nil {
+		return "", err
+	}
+	return cast.ToString(number / divisor), nil
+}
+
+func formatMul(value string, args ...string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if len(args) == 0 {
+		return "", errors.New("multiplier required,example: mul:100")
+	}
+	number, err := cast.ToFloat64E(value)
+	if err != nil {
+		return "", err
+	}
+	return cast.ToString(number * cast.ToFloat64(args[0])), nil
+}
+
+// formatFixed 保留小数位，例如：fixed:2
+func formatFixed(value string, args ...string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	precision := 2
+	if len(args) > 0 {
+		precision = cast.ToInt(args[0])
+	}
+	number, err := cast.ToFloat64E(value)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(number, 'f', precision, 64), nil
+}
+
+// formatMoney 分转元,保留2位小数
+func formatMoney(value string, args ...string) (string, error) {
+	value, err := formatDiv(value, "100")
+	if err != nil {
+		return "", err
+	}
+	return formatFixed(value, "2")
+}
+
+// formatMask 脱敏,保留前后字符，例如：mask:3,4 13812345678 => 138****5678
+func formatMask(value string, args ...string) (string, error) {
+	left, right, char := 3, 4, "*"
+	if len(args) > 0 {
+		left = cast.ToInt(args[0])
+	}
+	if len(args) > 1 {
+		right = cast.ToInt(args[1])
+	}
+	if len(args) > 2 && args[2] != "" {
+		char = args[2]
+	}
+	runes := []rune(value)
+	if len(runes) <= left+right {
+		return value, nil
+	}
+	masked := string(runes[:left]) + strings.Repeat(char, len(runes)-left-right) + string(runes[len(runes)-right:])
+	return masked, nil
+}
+
+// formatTruncate 截断，例如：truncate:20、truncate:20,…
+func formatTruncate(value string, args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("length required,example: truncate:20")
+	}
+	length := cast.ToInt(args[0])
+	suffix := "..."
+	if len(args) > 1 {
+		suffix = args[1]
+	}
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value, nil
+	}
+	return string(runes[:length]) + suffix, nil
+}
+
+// formatDefault 空值默认值，例如：default:-
+func formatDefault(value string, args ...string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	return strings.Join(args, ","), nil
+}
@@ -0,0 +1,49 @@
+package defined_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/defined"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		value  string
+		format string
+		expect string
+	}{
+		{value: "raw", format: "", expect: "raw"},
+		{value: "1761728045", format: "unixtime|date:2006-01-02", expect: time.Unix(1761728045, 0).Local().Format("2006-01-02")},
+		{value: "2025-10-29 16:54:05", format: "date:2006-01-02 15:04", expect: "2025-10-29 16:54"},
+		{value: "12345", format: "money", expect: "123.45"},
+		{value: "1500", format: "div:100|fixed:1", expect: "15.0"},
+		{value: "13812345678", format: "mask:3,4", expect: "138****5678"},
+		{value: "张三丰", format: "mask:1,0", expect: "张**"},
+		{value: "这是一段很长的备注", format: "truncate:4", expect: "这是一段..."},
+		{value: "", format: "default:-", expect: "-"},
+		{value: " abc ", format: "trim|upper", expect: "ABC"},
+	}
+	for _, c := range cases {
+		value, err := defined.Format(c.value, c.format)
+		require.NoError(t, err)
+		require.Equal(t, c.expect, value, c.format)
+	}
+
+	_, err := defined.Format("1", "notExists")
+	require.ErrorIs(t, err, defined.ErrorFormatterNotFound)
+
+	restore := defined.RegisterFormatter("wrap", func(value string, args ...string) (string, error) {
+		return args[0] + value + args[1], nil
+	})
+	t.Cleanup(restore)
+	fieldMeta := defined.FieldMeta{Name: "status", Dict: map[string]string{"1": "待使用"}, Format: "wrap:[,]"}
+	require.Equal(t, "[待使用]", fieldMeta.GetValue(1, map[string]string{"status": "1"}))
+	fieldMeta = defined.FieldMeta{Name: "amount", Format: "money"}
+	require.Contains(t, fieldMeta.GetValue(1, map[string]string{"amount": "abc"}), "formatter:money,value:abc") // 数据不符合格式时输出错误信息
+
+	restore()
+	_, err = defined.Format("1", "wrap:[,]")
+	require.ErrorIs(t, err, defined.ErrorFormatterNotFound)
+}
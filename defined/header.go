@@ -0,0 +1,96 @@
+package defined
+
+import (
+	"slices"
+	"strings"
+)
+
+// HeaderPath 标题路径(从上到下),未设置分组时为 [Title]
+func (fm FieldMeta) HeaderPath() []string {
+	if len(fm.Group) == 0 {
+		return []string{fm.Title}
+	}
+	return fm.Group
+}
+
+// HeaderDepth 标题行行数(标题路径最大层级)
+func (fs FieldMetas) HeaderDepth() int {
+	depth := 1
+	for _, fieldMeta := range fs {
+		depth = max(depth, len(fieldMeta.HeaderPath()))
+	}
+	return depth
+}
+
+// HeaderCell 标题单元格,行、列从0开始,EndRow、EndCol 与 Row、Col 不同时需要合并单元格
+type HeaderCell struct {
+	Title  string
+	Row    int
+	Col    int
+	EndRow int
+	EndCol int
+}
+
+// IsMerged 是否为合并单元格
+func (c HeaderCell) IsMerged() bool {
+	return c.EndRow > c.Row || c.EndCol > c.Col
+}
+
+// MakeHeaderCells 生成多级标题单元格,相邻列相同分组路径横向合并,层级不足的列最后一级标题纵向合并到最后一行
+func (fs FieldMetas) MakeHeaderCells() (cells []HeaderCell) {
+	depth := fs.HeaderDepth()
+	for row := 0; row < depth; row++ {
+		for col := 0; col < len(fs); col++ {
+			path := fs[col].HeaderPath()
+			if row >= len(path) {
+				continue // 已被上一级纵向合并
+			}
+			cell := HeaderCell{Title: path[row], Row: row, Col: col, EndRow: row, EndCol: col}
+			if row == len(path)-1 { // 最后一级标题
+				cell.EndRow = depth - 1
+				cells = append(cells, cell)
+				continue
+			}
+			for col+1 < len(fs) && isSameGroup(path, fs[col+1].HeaderPath(), row) {
+				col++
+			}
+			cell.EndCol = col
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// isSameGroup 判断两列在第 row 级是否属于同一分组(包含上级分组且都不是最后一级)
+func isSameGroup(path []string, other []string, row int) bool {
+	if row >= len(other)-1 {
+		return false
+	}
+	return slices.Equal(path[:row+1], other[:row+1])
+}
+
+// FitHeaderSize 合并标题宽度超过所跨列宽度之和时,增加最后一列宽度
+func (fs FieldMetas) FitHeaderSize() {
+	for _, cell := range fs.MakeHeaderCells() {
+		size := DisplayWidth(cell.Title)
+		if cell.Col == cell.EndCol {
+			fs[cell.Col].SetMaxSize(size)
+			continue
+		}
+		total := 0
+		for col := cell.Col; col <= cell.EndCol; col++ {
+			total += fs[col].GetMaxSize()
+		}
+		if size > total {
+			last := &fs[cell.EndCol]
+			last.SetMaxSize(last.GetMaxSize() + size - total)
+		}
+	}
+}
+
+func firstLine(s string) string {
+	if index := strings.Index(s, "\n"); index >= 0 {
+		return s[:index]
+	}
+	return s
+}
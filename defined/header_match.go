@@ -0,0 +1,142 @@
+package defined
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/width"
+)
+
+var (
+	ErrorHeaderNotFound = errors.New("header row not found")
+	ErrorHeaderMissing  = errors.New("required columns missing")
+)
+
+// NormalizeTitle 标题归一化(全角转半角、忽略大小写、空白字符及必填标记*),用于导入时匹配标题
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(width.Fold.String(title))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '*' {
+			return -1
+		}
+		return r
+	}, title)
+}
+
+// HeaderColumn 导入文件的标题列,Col 从0开始,Path 为从上到下非空的标题
+type HeaderColumn struct {
+	Col  int
+	Path []string
+}
+
+// Leaf 最后一级标题
+func (c HeaderColumn) Leaf() string {
+	if len(c.Path) == 0 {
+		return ""
+	}
+	return c.Path[len(c.Path)-1]
+}
+
+// MakeHeaderColumns 由标题行生成标题列,横向合并的上级标题只在第一列有值,同一行为空且下级标题不为空时沿用左侧标题
+func MakeHeaderColumns(rows [][]string) (columns []HeaderColumn) {
+	colCount := 0
+	for _, row := range rows {
+		colCount = max(colCount, len(row))
+	}
+	cell := func(row int, col int) string {
+		if col < len(rows[row]) {
+			return strings.TrimSpace(rows[row][col])
+		}
+		return ""
+	}
+	filled := make([][]string, len(rows))
+	for row := range rows {
+		filled[row] = make([]string, colCount)
+		for col := range colCount {
+			filled[row][col] = cell(row, col)
+		}
+	}
+	for col := range colCount {
+		hasLower := false
+		for row := len(rows) - 1; row >= 0; row-- {
+			if filled[row][col] == "" && hasLower && col > 0 {
+				filled[row][col] = filled[row][col-1]
+			}
+			hasLower = hasLower || cell(row, col) != ""
+		}
+	}
+	for col := range colCount {
+		column := HeaderColumn{Col: col}
+		for row := range rows {
+			if title := filled[row][col]; title != "" && (len(column.Path) == 0 || column.Path[len(column.Path)-1] != title) {
+				column.Path = append(column.Path, title)
+			}
+		}
+		if len(column.Path) > 0 {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// headerNames 列可匹配的标题(最后一级标题、列标题、别名)
+func (fm FieldMeta) headerNames() (names []string) {
+	path := fm.HeaderPath()
+	names = append(names, path[len(path)-1], fm.Title)
+	names = append(names, fm.Aliases...)
+	for i := range names {
+		names[i] = NormalizeTitle(names[i])
+	}
+	return names
+}
+
+// MatchColumns 按标题匹配列,设置多级标题的字段优先匹配完整标题路径,matched key 为字段序号,value 为列序号;missing 为未匹配的必需列
+func (fs FieldMetas) MatchColumns(columns []HeaderColumn) (matched map[int]int, missing FieldMetas) {
+	matched = make(map[int]int)
+	used := make(map[int]bool)
+	match := func(fieldIndex int, fn func(column HeaderColumn) bool) bool {
+		for _, column := range columns {
+			if !used[column.Col] && fn(column) {
+				matched[fieldIndex] = column.Col
+				used[column.Col] = true
+				return true
+			}
+		}
+		return false
+	}
+	for i, fieldMeta := range fs {
+		if len(fieldMeta.Group) > 1 {
+			path := normalizeTitles(fieldMeta.Group)
+			match(i, func(column HeaderColumn) bool { return slices.Equal(normalizeTitles(column.Path), path) })
+		}
+	}
+	for i, fieldMeta := range fs {
+		if _, ok := matched[i]; ok {
+			continue
+		}
+		names := fieldMeta.headerNames()
+		ok := match(i, func(column HeaderColumn) bool {
+			leaf := NormalizeTitle(column.Leaf())
+			for _, name := range names {
+				if name != "" && name == leaf {
+					return true
+				}
+			}
+			return false
+		})
+		if !ok && fieldMeta.Required {
+			missing = append(missing, fieldMeta)
+		}
+	}
+	return matched, missing
+}
+
+func normalizeTitles(titles []string) []string {
+	normalized := make([]string, len(titles))
+	for i, title := range titles {
+		normalized[i] = NormalizeTitle(title)
+	}
+	return normalized
+}
@@ -0,0 +1,127 @@
+package defined
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+const (
+	InferFieldPolicy_ignore = "ignore" // 后续页出现的新字段忽略(默认)
+	InferFieldPolicy_append = "append" // 后续页出现的新字段追加到最后一列
+)
+
+// SheetOptions 导出表格选项,可通过导出配置的 sheetOptions(json) 设置
+type SheetOptions struct {
+	InferFieldPolicy    string              `json:"inferFieldPolicy"`    // 未配置字段元数据时,自动推断列,后续页出现的新字段处理策略：ignore(默认)、append
+	HumanizeTitle       bool                `json:"humanizeTitle"`       // 自动推断列时,标题是否转换为易读格式，例如：createTime => Create Time
+	HeaderStyle         *CellStyle          `json:"headerStyle"`         // 标题行样式，例如：{"font":{"bold":true},"fill":"#D9E1F2","border":"#BFBFBF","horizontal":"center"}
+	BandedRowFill       string              `json:"bandedRowFill"`       // 斑马纹背景色(偶数数据行)，例如：#F2F2F2
+	FreezeHeader        bool                `json:"freezeHeader"`        // 冻结标题行
+	FreezeColumns       int                 `json:"freezeColumns"`       // 冻结前N列
+	AutoFilter          bool                `json:"autoFilter"`          // 标题行添加筛选(覆盖写入的数据范围)
+	Print               *PrintOptions       `json:"print"`               // 打印设置
+	FooterTitle         string              `json:"footerTitle"`         // 汇总行标题(写入第一列,第一列有汇总时忽略),默认：合计
+	FooterFormula       bool                `json:"footerFormula"`       // 汇总行使用 excel 公式(修改数据后汇总自动更新),默认写入计算结果
+	FooterStyle         *CellStyle          `json:"footerStyle"`         // 汇总行样式
+	PartitionField      string              `json:"partitionField"`      // 分表字段,按字段值将数据写入不同表单(同一文件,多表单导出时不支持)，例如：city
+	PartitionMaxSheets  int                 `json:"partitionMaxSheets"`  // 分表最大表单数,超过后新值的数据写入"其它"表单,默认：50
+	PartitionOtherSheet string              `json:"partitionOtherSheet"` // 超过最大表单数的数据写入的表单名称,默认：其它
+	Template            string              `json:"template"`            // 模板文件(xlsx),设置后复制模板导出,数据从 {{#rows}} 标记行开始写入并使用标记行样式,其它单元格占位符使用模板数据填充
+	ProtectPassword     string              `json:"protectPassword"`     // 表单保护密码(有锁定列时启用保护),为空则无密码
+	EditableRows        int                 `json:"editableRows"`        // 表单保护时数据之后追加的可编辑空行数(用于导入模板,有汇总行或模板尾部时不追加),默认：1000
+	Conditionals        []ConditionalFormat `json:"conditionals"`        // 表单级条件格式,field 指定应用的列,formula 规则未指定列时应用到整行，例如：[{"type":"formula","formula":"{expireDate}<TODAY()","style":{"fill":"#FFC7CE"}}]
+	WidthSampleRows     int                 `json:"widthSampleRows"`     // 计算列宽的样本行数,大于第一页数据时继续获取后续页作为样本,默认：第一页全部数据
+}
+
+const EditableRows_default = 1000
+
+// GetEditableRows 表单保护时数据之后追加的可编辑空行数
+func (o SheetOptions) GetEditableRows() int {
+	if o.EditableRows <= 0 {
+		return EditableRows_default
+	}
+	return o.EditableRows
+}
+
+const (
+	PartitionMaxSheets_default  = 50
+	PartitionOtherSheet_default = "其它"
+)
+
+// GetPartitionMaxSheets 分表最大表单数
+func (o SheetOptions) GetPartitionMaxSheets() int {
+	if o.PartitionMaxSheets <= 0 {
+		return PartitionMaxSheets_default
+	}
+	return o.PartitionMaxSheets
+}
+
+// GetPartitionOtherSheet 超过最大表单数的数据写入的表单名称
+func (o SheetOptions) GetPartitionOtherSheet() string {
+	if o.PartitionOtherSheet == "" {
+		return PartitionOtherSheet_default
+	}
+	return o.PartitionOtherSheet
+}
+
+const FooterTitle_default = "合计"
+
+// GetFooterTitle 汇总行标题
+func (o SheetOptions) GetFooterTitle() string {
+	if o.FooterTitle == "" {
+		return FooterTitle_default
+	}
+	return o.FooterTitle
+}
+
+const (
+	Orientation_portrait  = "portrait"
+	Orientation_landscape = "landscape"
+)
+
+// PrintOptions 打印设置
+type PrintOptions struct {
+	Orientation  string `json:"orientation"`  // 纸张方向：portrait(默认)、landscape
+	PaperSize    int    `json:"paperSize"`    // 纸张大小，例如：9(A4),0 使用默认值
+	FitToWidth   bool   `json:"fitToWidth"`   // 所有列缩放到一页宽
+	RepeatHeader bool   `json:"repeatHeader"` // 每页重复打印标题行
+}
+
+func (o *SheetOptions) Unmarshal(sheetOptionsStr string) (err error) {
+	if sheetOptionsStr == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(sheetOptionsStr), o)
+}
+
+// HumanizeTitle 将字段名转换为易读的标题，例如：createTime、create_time => Create Time
+func HumanizeTitle(name string) string {
+	words := make([]string, 0)
+	word := make([]rune, 0)
+	runes := []rune(name)
+	for i, r := range runes {
+		isSeparator := r == '_' || r == '-' || r == '.' || unicode.IsSpace(r)
+		isBoundary := unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+		if (isSeparator || isBoundary) && len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+		if !isSeparator {
+			word = append(word, r)
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	for i, w := range words {
+		if strings.EqualFold(w, "id") {
+			words[i] = "ID"
+			continue
+		}
+		wordRunes := []rune(w)
+		wordRunes[0] = unicode.ToUpper(wordRunes[0])
+		words[i] = string(wordRunes)
+	}
+	return strings.Join(words, " ")
+}
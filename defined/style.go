@@ -0,0 +1,82 @@
+package defined
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CellStyle 单元格样式,写入时转换为 excelize 样式并只注册一次
+type CellStyle struct {
+	Font       *FontStyle `json:"font,omitempty"`
+	Fill       string     `json:"fill,omitempty"`       // 背景色，例如：#D9E1F2
+	Border     string     `json:"border,omitempty"`     // 四周细边框颜色，例如：#BFBFBF,为空则无边框
+	Horizontal string     `json:"horizontal,omitempty"` // 水平对齐：left、center、right
+	Vertical   string     `json:"vertical,omitempty"`   // 垂直对齐：top、center、bottom
+	WrapText   bool       `json:"wrapText,omitempty"`   // 自动换行
+	NumFmt     string     `json:"numFmt,omitempty"`     // 数字格式，例如：#,##0.00、0.00%、yyyy-mm-dd(需配合 type=number 使用)
+	Unlocked   bool       `json:"unlocked,omitempty"`   // 取消锁定(表单保护时可编辑)
+}
+
+type FontStyle struct {
+	Bold      bool    `json:"bold,omitempty"`
+	Italic    bool    `json:"italic,omitempty"`
+	Size      float64 `json:"size,omitempty"`
+	Color     string  `json:"color,omitempty"` // 字体颜色，例如：#FFFFFF
+	Family    string  `json:"family,omitempty"`
+	Underline bool    `json:"underline,omitempty"`
+}
+
+// RichTextRun 富文本片段
+type RichTextRun struct {
+	Text string     `json:"text"`
+	Font *FontStyle `json:"font,omitempty"`
+}
+
+// ParseRichText 解析富文本(RichTextRun 数组json)，例如：[{"text":"逾期","font":{"bold":true,"color":"#FF0000"}},{"text":"3天"}],非富文本返回 false
+func ParseRichText(value string) (runs []RichTextRun, ok bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") {
+		return nil, false
+	}
+	err := json.Unmarshal([]byte(value), &runs)
+	if err != nil || len(runs) == 0 {
+		return nil, false
+	}
+	return runs, true
+}
+
+// Merge 合并样式,other 中非空的属性覆盖当前样式,返回新样式
+func (s *CellStyle) Merge(other *CellStyle) *CellStyle {
+	merged := CellStyle{}
+	if s != nil {
+		merged = *s
+	}
+	if other == nil {
+		return &merged
+	}
+	if other.Font != nil {
+		merged.Font = other.Font
+	}
+	if other.Fill != "" {
+		merged.Fill = other.Fill
+	}
+	if other.Border != "" {
+		merged.Border = other.Border
+	}
+	if other.Horizontal != "" {
+		merged.Horizontal = other.Horizontal
+	}
+	if other.Vertical != "" {
+		merged.Vertical = other.Vertical
+	}
+	if other.WrapText {
+		merged.WrapText = true
+	}
+	if other.NumFmt != "" {
+		merged.NumFmt = other.NumFmt
+	}
+	if other.Unlocked {
+		merged.Unlocked = true
+	}
+	return &merged
+}
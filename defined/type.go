@@ -3,6 +3,9 @@ package defined
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/hoisie/mustache"
@@ -12,13 +15,40 @@ import (
 )
 
 type FieldMeta struct {
-	Title    string `json:"title"` // 列标题
-	Name     string `json:"name"`  // 列值模板，例如：{{nameField}}({{idField}}),如果只有一个字段，则可以省略{{}}
-	maxSize  int    // 当前列字符串最多的个数(用来调整列宽)
-	template *mustache.Template
-	err      error
+	Title         string              `json:"title"`                   // 列标题
+	Name          string              `json:"name"`                    // 列值模板，例如：{{nameField}}({{idField}}),如果只有一个字段，则可以省略{{}}
+	Dict          map[string]string   `json:"dict,omitempty"`          // 值映射字典，例如：{"1":"待使用","2":"已使用"}
+	DictKey       string              `json:"dictKey,omitempty"`       // 引用共享字典(导出字典表)的键,与 Dict 合并,Dict 优先
+	DictDefault   string              `json:"dictDefault,omitempty"`   // 字典中不存在的值显示的默认值,为空则显示原值
+	DictSeparator string              `json:"dictSeparator,omitempty"` // 多值分隔符，例如：",",设置后按分隔符拆分后逐个映射
+	Format        string              `json:"format,omitempty"`        // 格式化管道(字典映射之后执行)，例如：unixtime|date:2006-01-02、money、mask:3,4,可通过 RegisterFormatter 注册自定义格式化函数
+	Type          string              `json:"type,omitempty"`          // 单元格类型：string(默认)、number(可解析为数字时按数字写入,配合 style.numFmt 使用)、link(超链接)、image(图片,列值为url,需设置图片加载函数,未设置时显示url)、richtext(富文本,列值为 RichTextRun 数组json)
+	Link          string              `json:"link,omitempty"`          // 超链接地址模板(type=link),列值为显示文本，例如：https://example.com/order/{{id}}
+	Options       []string            `json:"options,omitempty"`       // 允许的值(数据行添加下拉列表校验)，例如：["待使用","已使用"]
+	Locked        bool                `json:"locked,omitempty"`        // 锁定列(数据不可编辑),有锁定列时启用表单保护,其它列可编辑
+	Conditionals  []ConditionalFormat `json:"conditionals,omitempty"`  // 条件格式，例如：[{"type":"cell","operator":"<","value":"0","style":{"font":{"color":"#FF0000"}}}]
+	Style         *CellStyle          `json:"style,omitempty"`         // 列样式(数据行)
+	Group         []string            `json:"group,omitempty"`         // 多级标题路径(从上到下,包含本列标题)，例如：["买家","姓名"],相邻列相同的上级分组合并单元格
+	Aggregate     string              `json:"aggregate,omitempty"`     // 汇总方式(在末尾追加汇总行)：sum、count、avg、min、max(可解析为数字的值按数字写入)、distinct(去重计数)
+	Width         int                 `json:"width,omitempty"`         // 固定列宽(字符数),设置后不根据内容计算
+	MinWidth      int                 `json:"minWidth,omitempty"`      // 最小列宽(字符数)
+	MaxWidth      int                 `json:"maxWidth,omitempty"`      // 最大列宽(字符数),默认：ColumnMaxSize
+	Aliases       []string            `json:"aliases,omitempty"`       // 导入时可匹配的标题别名(忽略大小写、空白字符)，例如：["手机号","联系电话"]
+	Required      bool                `json:"required,omitempty"`      // 导入时必须存在的列,按标题匹配未找到时报错
+	Validate      string              `json:"validate,omitempty"`      // 导入校验规则(go-playground/validator),type=number 时按数字校验，例如：required,email、omitempty,oneof=待使用 已使用、gte=0
+	maxSize       int                 // 当前列内容最大显示宽度(用来调整列宽)
+	template      *mustache.Template
+	err           error
 }
 
+const (
+	FieldType_string   = "string"
+	FieldType_number   = "number"
+	FieldType_link     = "link"
+	FieldType_image    = "image"
+	FieldType_richtext = "richtext"
+)
+
 var ErrorFieldMeta = errors.Errorf("FieldMeta.Name is empty")
 
 func (fm *FieldMeta) parseTpl() (*mustache.Template, error) {
@@ -39,6 +69,44 @@ func (fm *FieldMeta) parseTpl() (*mustache.Template, error) {
 }
 
 func (fm FieldMeta) GetValue(rowNumber int, row map[string]string) string {
+	value := fm.getRawValue(rowNumber, row)
+	value = fm.Translate(value)
+	if fm.Format != "" {
+		formatted, err := Format(value, fm.Format)
+		if err != nil { // 配置错误、数据不符合格式(如非数字),与模板错误一样输出错误信息方便排查
+			return err.Error()
+		}
+		value = formatted
+	}
+	return value
+}
+
+// Translate 使用字典将原始值转换为显示值
+func (fm FieldMeta) Translate(value string) string {
+	if len(fm.Dict) == 0 {
+		return value
+	}
+	if fm.DictSeparator == "" {
+		return fm.translateOne(value)
+	}
+	values := strings.Split(value, fm.DictSeparator)
+	for i, v := range values {
+		values[i] = fm.translateOne(strings.TrimSpace(v))
+	}
+	return strings.Join(values, fm.DictSeparator)
+}
+
+func (fm FieldMeta) translateOne(value string) string {
+	if label, ok := fm.Dict[value]; ok {
+		return label
+	}
+	if fm.DictDefault != "" {
+		return fm.DictDefault
+	}
+	return value
+}
+
+func (fm FieldMeta) getRawValue(rowNumber int, row map[string]string) string {
 	if fm.err != nil {
 		return fm.err.Error()
 	}
@@ -49,6 +117,13 @@ func (fm FieldMeta) GetValue(rowNumber int, row map[string]string) string {
 	if value, ok := m[fm.Name]; ok {
 		return cast.ToString(value)
 	}
+	if slices.ContainsFunc(fm.Paths(), IsNestedPath) { // mustache 会将.当作层级分隔符,嵌套路径直接替换
+		value := tplVarExp.ReplaceAllStringFunc(fm.Name, func(placeholder string) string {
+			path := tplVarExp.FindStringSubmatch(placeholder)[1]
+			return row[path]
+		})
+		return value
+	}
 	tpl, err := fm.parseTpl()
 	if err != nil {
 		return err.Error()
@@ -58,11 +133,38 @@ func (fm FieldMeta) GetValue(rowNumber int, row map[string]string) string {
 }
 func (fm FieldMeta) GetMaxSize() int { return fm.maxSize }
 
+// GetLink 获取超链接地址,未设置链接模板返回空
+func (fm FieldMeta) GetLink(rowNumber int, row map[string]string) string {
+	if fm.Link == "" {
+		return ""
+	}
+	linkMeta := FieldMeta{Name: fm.Link}
+	return linkMeta.getRawValue(rowNumber, row)
+}
+
+var tplVarExp = regexp.MustCompile(`{{\s*([^{}#^/!>&\s]+)\s*}}`)
+
+// Paths 获取列值引用的字段(支持gjson路径，例如：buyer.address.city、items.#.sku)
+func (fm FieldMeta) Paths() (paths []string) {
+	if !strings.Contains(fm.Name, "{{") {
+		return []string{fm.Name}
+	}
+	for _, match := range tplVarExp.FindAllStringSubmatch(fm.Name, -1) {
+		paths = append(paths, match[1])
+	}
+	return paths
+}
+
+// IsNestedPath 判断字段名是否为嵌套路径(需要通过gjson 获取值)
+func IsNestedPath(name string) bool {
+	return strings.ContainsAny(name, ".#")
+}
+
 var ColumnMaxSize = 100 // 列宽最大值
 
 func (fm *FieldMeta) SetMaxSize(size int) {
-	if size > ColumnMaxSize {
-		size = ColumnMaxSize // 列宽最大值限制
+	if maxWidth := fm.maxWidth(); size > maxWidth {
+		size = maxWidth // 列宽最大值限制
 
 	}
 	if fm.maxSize < size {
@@ -72,6 +174,16 @@ func (fm *FieldMeta) SetMaxSize(size int) {
 
 type FieldMetas []FieldMeta
 
+// HasLocked 是否有锁定列
+func (fs FieldMetas) HasLocked() bool {
+	return slices.ContainsFunc(fs, func(fieldMeta FieldMeta) bool { return fieldMeta.Locked })
+}
+
+// HasAggregate 是否有列需要汇总
+func (fs FieldMetas) HasAggregate() bool {
+	return slices.ContainsFunc(fs, func(fieldMeta FieldMeta) bool { return fieldMeta.Aggregate != "" })
+}
+
 func (fs FieldMetas) MakeTitleRow() map[string]string {
 	m := make(map[string]string)
 	for _, fieldMeta := range fs {
@@ -80,6 +192,70 @@ func (fs FieldMetas) MakeTitleRow() map[string]string {
 	return m
 
 }
+
+// NestedPaths 获取所有列引用的嵌套路径
+func (fs FieldMetas) NestedPaths() (paths []string) {
+	for _, fieldMeta := range fs {
+		fieldPaths := fieldMeta.Paths()
+		if fieldMeta.Link != "" {
+			fieldPaths = append(fieldPaths, FieldMeta{Name: fieldMeta.Link}.Paths()...)
+		}
+		for _, path := range fieldPaths {
+			if IsNestedPath(path) && !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// DictKeys 获取引用的共享字典键
+func (fs FieldMetas) DictKeys() (dictKeys []string) {
+	for _, fieldMeta := range fs {
+		if fieldMeta.DictKey != "" && !slices.Contains(dictKeys, fieldMeta.DictKey) {
+			dictKeys = append(dictKeys, fieldMeta.DictKey)
+		}
+	}
+	return dictKeys
+}
+
+// ImageLoader 图片加载函数,src 为图片列值(url),extension 为图片扩展名，例如：.png
+type ImageLoader func(src string) (data []byte, extension string, err error)
+
+// DictLoader 共享字典加载函数,返回 字典键=>字典
+type DictLoader func(dictKeys ...string) (dicts map[string]map[string]string, err error)
+
+// LoadDicts 加载共享字典,合并到 Dict 中(Dict 中已有的值优先)
+func (fs FieldMetas) LoadDicts(loader DictLoader) (err error) {
+	dictKeys := fs.DictKeys()
+	if len(dictKeys) == 0 {
+		return nil
+	}
+	if loader == nil {
+		err = errors.Errorf("DictLoader required, dictKeys:%s", strings.Join(dictKeys, ","))
+		return err
+	}
+	dicts, err := loader(dictKeys...)
+	if err != nil {
+		return err
+	}
+	for i := range fs {
+		if fs[i].DictKey == "" {
+			continue
+		}
+		dict, ok := dicts[fs[i].DictKey]
+		if !ok {
+			err = errors.Errorf("dict not found, dictKey:%s", fs[i].DictKey)
+			return err
+		}
+		merged := make(map[string]string, len(dict)+len(fs[i].Dict))
+		maps.Copy(merged, dict)
+		maps.Copy(merged, fs[i].Dict)
+		fs[i].Dict = merged
+	}
+	return nil
+}
+
 func (fs FieldMetas) Empty() bool {
 	return len(fs) == 0
 }
@@ -92,6 +268,10 @@ func (fs *FieldMetas) Unmarshal(fieldMetasStr string) (err error) {
 }
 
 type RecordFormatFn func(record map[string]string) (newRecord map[string]string, err error)
+
+// RecordsFormatFn 记录处理函数,一条记录可返回0条(过滤)、1条(格式化)或多条(展开)记录
+type RecordsFormatFn func(record map[string]string) (newRecords []map[string]string, err error)
+
 type RequestFormatFn func(requestDTO httpraw.RequestDTO) (newRequestDTO httpraw.RequestDTO, err error)
 type ResponseFormatFn func(responseDTO httpraw.ResponseDTO) (records []map[string]any, err error)
 type Setting struct {
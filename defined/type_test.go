@@ -0,0 +1,40 @@
+package defined_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/defined"
+)
+
+func TestFieldMetaDict(t *testing.T) {
+	fieldMetas := defined.FieldMetas{
+		{Name: "status", Title: "状态", Dict: map[string]string{"1": "待使用"}, DictKey: "orderStatus", DictDefault: "未知"},
+		{Name: "tags", Title: "标签", DictKey: "tag", DictSeparator: ","},
+	}
+	err := fieldMetas.LoadDicts(func(dictKeys ...string) (dicts map[string]map[string]string, err error) {
+		dicts = map[string]map[string]string{
+			"orderStatus": {"1": "待支付", "2": "已使用"},
+			"tag":         {"a": "新品", "b": "热卖"},
+		}
+		return dicts, nil
+	})
+	require.NoError(t, err)
+	row := map[string]string{"status": "1", "tags": "a,b,c"}
+	require.Equal(t, "待使用", fieldMetas[0].GetValue(1, row))
+	require.Equal(t, "已使用", fieldMetas[0].GetValue(1, map[string]string{"status": "2"}))
+	require.Equal(t, "未知", fieldMetas[0].GetValue(1, map[string]string{"status": "9"}))
+	require.Equal(t, "新品,热卖,c", fieldMetas[1].GetValue(1, row))
+
+	err = defined.FieldMetas{{Name: "status", DictKey: "missing"}}.LoadDicts(nil)
+	require.Error(t, err)
+}
+
+func TestDisplayWidth(t *testing.T) {
+	require.Equal(t, 5, defined.DisplayWidth("hello"))
+	require.Equal(t, 4, defined.DisplayWidth("中文"))
+	require.Equal(t, 7, defined.DisplayWidth("ａb中文"))
+	require.Equal(t, 2, defined.DisplayWidth("👍"))
+	require.Equal(t, 2, defined.DisplayWidth("👍\ufe0f"))
+	require.Equal(t, 3, defined.DisplayWidth("abc\n中文中文"))
+}
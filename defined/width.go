@@ -0,0 +1,49 @@
+package defined
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// DisplayWidth 字符串显示宽度(只计算第一行),东亚宽字符、全角字符及表情计为2,组合字符、零宽字符计为0,其它字符计为1
+func DisplayWidth(s string) int {
+	total := 0
+	for _, r := range firstLine(s) {
+		total += runeWidth(r)
+	}
+	return total
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf): // 组合字符、变体选择符、零宽连接符等
+		return 0
+	case r >= 0x1F000: // 表情等补充平面符号
+		return 2
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// ColumnWidth 列宽,设置固定列宽时使用固定列宽,否则使用内容最大宽度并限制在最小、最大列宽之间
+func (fm FieldMeta) ColumnWidth() int {
+	if fm.Width > 0 {
+		return fm.Width
+	}
+	size := min(fm.maxSize, fm.maxWidth())
+	if fm.MinWidth > 0 {
+		size = max(size, fm.MinWidth)
+	}
+	return size
+}
+
+func (fm FieldMeta) maxWidth() int {
+	if fm.MaxWidth > 0 {
+		return fm.MaxWidth
+	}
+	return ColumnMaxSize
+}
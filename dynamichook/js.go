@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/dop251/goja"
 	"github.com/google/uuid"
@@ -85,6 +86,54 @@ func (jsVm *JSVM) RecordFormatFn(fnName string) (fn defined.RecordFormatFn, err
 
 }
 
+// RecordsFormatFn 获取记录处理函数,js 函数返回 null/undefined 过滤该记录,返回数组则展开为多条记录,返回对象则为一条记录
+func (jsVm *JSVM) RecordsFormatFn(fnName string) (fn defined.RecordsFormatFn, err error) {
+	fn = func(record map[string]string) (newRecords []map[string]string, err error) {
+		return []map[string]string{record}, nil
+	}
+	jsFunc, err := jsVm.GetJSFn(fnName)
+	if err != nil {
+		err = errors.WithMessage(err, "RecordsFormatFn GetJSFn error")
+		return fn, err
+	}
+	vm := jsVm.vm
+	fn = func(record map[string]string) (newRecords []map[string]string, err error) {
+		res, err := jsFunc(goja.Undefined(), vm.ToValue(record))
+		if err != nil {
+			return nil, fmt.Errorf("RecordsFormatFn js execution error: %w", err)
+		}
+		if goja.IsUndefined(res) || goja.IsNull(res) { // 过滤
+			return nil, nil
+		}
+		var recordsAny []map[string]any
+		if res.ExportType() != nil && res.ExportType().Kind() == reflect.Slice {
+			if err := vm.ExportTo(res, &recordsAny); err != nil {
+				return nil, fmt.Errorf("RecordsFormatFn export js result error: %w", err)
+			}
+		} else {
+			var recordAny map[string]any
+			if err := vm.ExportTo(res, &recordAny); err != nil {
+				return nil, fmt.Errorf("RecordsFormatFn export js result error: %w", err)
+			}
+			recordsAny = append(recordsAny, recordAny)
+		}
+
+		newRecords = make([]map[string]string, 0, len(recordsAny))
+		for _, recordAny := range recordsAny {
+			if recordAny == nil {
+				continue
+			}
+			newRecord := make(map[string]string)
+			for k, v := range recordAny {
+				newRecord[k] = cast.ToString(v)
+			}
+			newRecords = append(newRecords, newRecord)
+		}
+		return newRecords, nil
+	}
+	return fn, nil
+}
+
 func (jsVm *JSVM) ResponseFormatFn(fnName string) (fn defined.ResponseFormatFn, err error) {
 	fn = func(responseDTO httpraw.ResponseDTO) (records []map[string]any, err error) {
 		records = make([]map[string]any, 0)
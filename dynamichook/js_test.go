@@ -0,0 +1,47 @@
+package dynamichook_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/dynamichook"
+)
+
+func TestRecordsFormatFn(t *testing.T) {
+	script := `
+function recordFormatFn(record){
+	if (record.test === "1") {
+		return null
+	}
+	if (record.items) {
+		return JSON.parse(record.items).map(function(item){
+			return {orderId: record.orderId, sku: item.sku}
+		})
+	}
+	record.statusText = record.status === "1" ? "待使用" : "其它"
+	return record
+}`
+	jsvm, err := dynamichook.ParseJSVM(script)
+	require.NoError(t, err)
+	fn, err := jsvm.RecordsFormatFn("recordFormatFn")
+	require.NoError(t, err)
+
+	t.Run("filter", func(t *testing.T) {
+		records, err := fn(map[string]string{"orderId": "1", "test": "1"})
+		require.NoError(t, err)
+		require.Len(t, records, 0)
+	})
+	t.Run("expand", func(t *testing.T) {
+		records, err := fn(map[string]string{"orderId": "2", "items": `[{"sku":"a"},{"sku":"b"}]`})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "b", records[1]["sku"])
+		require.Equal(t, "2", records[1]["orderId"])
+	})
+	t.Run("format", func(t *testing.T) {
+		records, err := fn(map[string]string{"orderId": "3", "status": "1"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "待使用", records[0]["statusText"])
+	})
+}
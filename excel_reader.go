@@ -21,6 +21,7 @@ func OpenReader(r io.Reader) (f *excelize.File, err error) {
 }
 
 type _ExcelReader struct {
+	rawCellValue bool // 读取单元格原始值(不应用数字格式,日期为序列号)
 }
 
 // NewExcelReader 实例化 excel reader服务
@@ -28,6 +29,16 @@ func NewExcelReader() *_ExcelReader {
 	return &_ExcelReader{}
 }
 
+// WithRawCellValue 读取单元格原始值,日期读取为 excel 序列号,数字不应用千分位、百分比等格式
+func (instance *_ExcelReader) WithRawCellValue(rawCellValue bool) *_ExcelReader {
+	instance.rawCellValue = rawCellValue
+	return instance
+}
+
+func (instance *_ExcelReader) cellOptions() excelize.Options {
+	return excelize.Options{RawCellValue: instance.rawCellValue}
+}
+
 // Read 读取excel 表中所有数据 fieldMap key 为 a、b、c等excel列名称,value为这列转换为记录的属性名  UnmergeCell 将合并单元格展开，值填充到每个展开的单元内
 func (instance *_ExcelReader) Read(f *excelize.File, sheet string, fieldMap map[string]string, rowIndex int, isUnmergeCell bool) ([]map[string]string, error) {
 	if isUnmergeCell {
@@ -37,39 +48,57 @@ func (instance *_ExcelReader) Read(f *excelize.File, sheet string, fieldMap map[
 		}
 	}
 	// 获取 Sheet 上所有单元格
-	rows, err := f.GetRows(sheet)
+	rows, err := f.GetRows(sheet, instance.cellOptions())
 	if err != nil {
 		return nil, err
 	}
 	output := make([]map[string]string, 0)
-	for k, v := range fieldMap {
-		fieldMap[strings.ToUpper(k)] = v // 兼容 大小写
-	}
-
+	fieldMap = normalizeFieldMap(fieldMap)
 	for index, row := range rows {
 		if index < rowIndex-1 { // 从指定行开始读取
 			continue
 		}
-		record := make(map[string]string, 0)
-		for colIndex, colCell := range row {
-			colName, err := excelize.ColumnNumberToName(colIndex + 1)
-			if err != nil {
-				return nil, err
-			}
-			if fieldMap != nil { // 如果定制了列名和字段映射关系，替换字段映射
-				field, ok := fieldMap[colName]
-				if ok {
-					record[field] = colCell
-				}
-			} else {
-				record[colName] = colCell
-			}
+		record, err := makeRecord(row, fieldMap)
+		if err != nil {
+			return nil, err
 		}
 		output = append(output, record)
 	}
 	return output, nil
 }
 
+// normalizeFieldMap 列名称转换为大写(兼容大小写)
+func normalizeFieldMap(fieldMap map[string]string) map[string]string {
+	if fieldMap == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(fieldMap))
+	for k, v := range fieldMap {
+		normalized[strings.ToUpper(k)] = v
+	}
+	return normalized
+}
+
+// makeRecord 将一行单元格转换为记录,fieldMap 为空时使用列名称(A、B、C等)作为属性名
+func makeRecord(row []string, fieldMap map[string]string) (record map[string]string, err error) {
+	record = make(map[string]string, 0)
+	for colIndex, colCell := range row {
+		colName, err := excelize.ColumnNumberToName(colIndex + 1)
+		if err != nil {
+			return nil, err
+		}
+		if fieldMap != nil { // 如果定制了列名和字段映射关系，替换字段映射
+			field, ok := fieldMap[colName]
+			if ok {
+				record[field] = colCell
+			}
+		} else {
+			record[colName] = colCell
+		}
+	}
+	return record, nil
+}
+
 // UnmergeCell 将合并单元格展开，值填充到每个展开的单元内
 func (instance *_ExcelReader) UnmergeCell(f *excelize.File, sheet string) (err error) {
 	mergeCells, err := f.GetMergeCells(sheet)
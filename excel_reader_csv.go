@@ -0,0 +1,455 @@
+package excelrw
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	FileFormat_xlsx = "xlsx"
+	FileFormat_xls  = "xls"
+	FileFormat_csv  = "csv"
+)
+
+var (
+	ErrorFileFormatNotSupported = errors.New("file format not supported")
+	ErrorCSVTooManyRows         = errors.New("csv rows exceed excel max rows, use NewCSVReader")
+	ErrorCSVAlreadyRead         = errors.New("csv already read")
+	ErrorCSVEncoding            = errors.New("csv encoding not supported")
+)
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1} // .xls 等 OLE 复合文档
+	utf8BOM  = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// csvDelimiters 自动识别的分隔符
+var csvDelimiters = []rune{',', '\t', ';', '|'}
+
+const (
+	csvSniffLines = 10       // 识别分隔符时采样的行数
+	csvSniffSize  = 64 << 10 // 识别编码、分隔符时预读的字节数
+)
+
+// DetectFileFormat 识别上传文件格式,优先按文件头(xlsx 为 zip、xls 为 OLE 复合文档),其次按扩展名,其余按 csv 处理
+func DetectFileFormat(filename string, head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		return FileFormat_xlsx
+	case bytes.HasPrefix(head, oleMagic):
+		return FileFormat_xls
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx", ".xlsm", ".xltx", ".xltm":
+		return FileFormat_xlsx
+	case ".xls":
+		return FileFormat_xls
+	}
+	return FileFormat_csv
+}
+
+// OpenUpload 按格式打开上传文件(xlsx、xls、csv、tsv),xls(OpenXls)、csv(OpenCSV,表单 SheetDefault)读取到内存中的 excel 文件,与 xlsx 使用相同的读取方法
+func OpenUpload(filename string, r io.Reader) (f *excelize.File, err error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(oleMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	format := DetectFileFormat(filename, head)
+	switch format {
+	case FileFormat_xlsx:
+		return OpenReader(br)
+	case FileFormat_xls:
+		return OpenXls(br)
+	case FileFormat_csv:
+		var delimiter rune
+		if strings.EqualFold(filepath.Ext(filename), ".tsv") {
+			delimiter = '\t'
+		}
+		return OpenCSV(br, delimiter)
+	}
+	err = errors.WithMessagef(ErrorFileFormatNotSupported, "filename:%s,format:%s", filename, format)
+	return nil, err
+}
+
+// OpenCSV 读取 csv 到内存中的 excel 文件(表单 SheetDefault,单元格均为文本),delimiter 为0时自动识别;
+// 自动去除 BOM,非 UTF-8 编码按 GB18030(兼容 GBK)转换;数据量大(超过 excel 最大行数)时使用 NewCSVReader 流式读取
+func OpenCSV(r io.Reader, delimiter rune) (f *excelize.File, err error) {
+	cr, err := NewCSVReader(r, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	f = excelize.NewFile()
+	err = f.SetSheetName(f.GetSheetName(0), SheetDefault)
+	if err != nil {
+		return nil, err
+	}
+	sw, err := f.NewStreamWriter(SheetDefault)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		row, err := cr.readRow()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cr.rowNumber > excelize.TotalRows {
+			err = errors.WithMessagef(ErrorCSVTooManyRows, "max rows:%d", excelize.TotalRows)
+			return nil, err
+		}
+		values := make([]any, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+		cell, err := excelize.CoordinatesToCellName(1, cr.rowNumber)
+		if err != nil {
+			return nil, err
+		}
+		err = sw.SetRow(cell, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = sw.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// CSVReader csv 流式读取器,逐行读取(不加载全部数据、不转换为 excel 文件,不受 excel 最大行数限制),
+// 返回的记录与 ReadStream、ReadStreamByHeader 一致;数据只能读取一次
+type CSVReader struct {
+	reader    *csv.Reader
+	topRows   [][]string // MatchHeader 预读、未消费的行
+	rowNumber int        // 已消费的行数(行号从1开始)
+}
+
+// NewCSVReader 创建 csv 流式读取器,delimiter 为0时自动识别;自动去除 BOM,非 UTF-8 编码按 GB18030(兼容 GBK)转换(分隔符按文件开头识别,开头均为 ASCII 时编码按第一个非 ASCII 字符所在行识别)
+func NewCSVReader(r io.Reader, delimiter rune) (cr *CSVReader, err error) {
+	src, head, err := decodeCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if delimiter == 0 {
+		delimiter = detectDelimiter(head)
+	}
+	reader := csv.NewReader(src)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	cr = &CSVReader{reader: reader}
+	return cr, nil
+}
+
+// readRow 读取下一行,去除行尾空单元格(与 xlsx 读取一致),读取完成时返回 io.EOF
+func (cr *CSVReader) readRow() (row []string, err error) {
+	if len(cr.topRows) > 0 {
+		row, cr.topRows = cr.topRows[0], cr.topRows[1:]
+		cr.rowNumber++
+		return row, nil
+	}
+	row, err = cr.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cr.rowNumber++
+	for len(row) > 0 && row[len(row)-1] == "" {
+		row = row[:len(row)-1]
+	}
+	return row, nil
+}
+
+// readTopRows 预读前 n 行(不消费,ReadStream 时重新返回)
+func (cr *CSVReader) readTopRows(n int) (rows [][]string, err error) {
+	for len(cr.topRows) < n {
+		row, err := cr.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		cr.topRows = append(cr.topRows, row)
+	}
+	return cr.topRows[:min(n, len(cr.topRows))], nil
+}
+
+// MatchHeader 定位标题行并按标题匹配字段,与 _ExcelReader.MatchHeader 相同,需在 ReadStream 之前调用
+func (cr *CSVReader) MatchHeader(fieldMetas defined.FieldMetas, options HeaderOptions) (fieldMap map[string]string, rowIndex int, err error) {
+	if cr.rowNumber > 0 {
+		err = errors.WithMessagef(ErrorCSVAlreadyRead, "rowNumber:%d", cr.rowNumber)
+		return nil, 0, err
+	}
+	return matchHeader(SheetDefault, fieldMetas, options, cr.readTopRows)
+}
+
+// ReadStream 流式读取,每 batchSize 行回调一次,fieldMap、rowIndex 与 _ExcelReader.ReadStream 相同
+func (cr *CSVReader) ReadStream(ctx context.Context, fieldMap map[string]string, rowIndex int, batchSize int, fn ReadBatchFn) (err error) {
+	if batchSize <= 0 {
+		batchSize = ReadBatchSize_default
+	}
+	fieldMap = normalizeFieldMap(fieldMap)
+	batch := make([]map[string]string, 0, batchSize)
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		row, err := cr.readRow()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if cr.rowNumber < rowIndex { // 从指定行开始读取
+			continue
+		}
+		record, err := makeRecord(row, fieldMap)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, record)
+		if len(batch) < batchSize {
+			continue
+		}
+		err = fn(batch)
+		if err != nil {
+			return err
+		}
+		batch = make([]map[string]string, 0, batchSize)
+	}
+	if len(batch) > 0 {
+		err = fn(batch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadStreamByHeader 按标题行匹配列流式读取数据
+func (cr *CSVReader) ReadStreamByHeader(ctx context.Context, fieldMetas defined.FieldMetas, options HeaderOptions, batchSize int, fn ReadBatchFn) (err error) {
+	fieldMap, rowIndex, err := cr.MatchHeader(fieldMetas, options)
+	if err != nil {
+		return err
+	}
+	return cr.ReadStream(ctx, fieldMap, rowIndex, batchSize, fn)
+}
+
+// decodeCSV 按文件开头识别编码,返回转换为 UTF-8(去除 BOM)的数据流及转换后的文件开头(用于识别分隔符);
+// 开头均为 ASCII 时在第一个非 ASCII 字符处按所在行重新识别,UTF-8 数据流中出现非 UTF-8 内容时读取返回 ErrorCSVEncoding
+func decodeCSV(r io.Reader) (src io.Reader, head []byte, err error) {
+	br := bufio.NewReaderSize(r, csvSniffSize)
+	head, err = br.Peek(csvSniffSize)
+	atEOF := errors.Is(err, io.EOF)
+	if err != nil && !atEOF {
+		return nil, nil, err
+	}
+	if bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF}) {
+		decoding := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		head, _, err = transform.Bytes(decoding.NewDecoder(), head)
+		if err != nil {
+			return nil, nil, err
+		}
+		return transform.NewReader(br, decoding.NewDecoder()), head, nil
+	}
+	if i := bytes.LastIndexByte(head, '\n'); !atEOF && i >= 0 { // 去除不完整的最后一行,避免截断多字节字符
+		head = head[:i+1]
+	}
+	if bytes.HasPrefix(head, utf8BOM) {
+		head = bytes.Clone(head[len(utf8BOM):])
+		_, err = br.Discard(len(utf8BOM))
+		if err != nil {
+			return nil, nil, err
+		}
+		return &utf8CheckReader{br: br, offset: int64(len(utf8BOM))}, head, nil
+	}
+	if isASCII(head) {
+		return &utf8CheckReader{br: br, ascii: true}, head, nil
+	}
+	if utf8.Valid(head) {
+		return &utf8CheckReader{br: br}, head, nil
+	}
+	head, _, err = transform.Bytes(simplifiedchinese.GB18030.NewDecoder(), head)
+	if err != nil {
+		err = errors.WithMessage(err, "decode csv as GB18030")
+		return nil, nil, err
+	}
+	return transform.NewReader(br, simplifiedchinese.GB18030.NewDecoder()), head, nil
+}
+
+// utf8CheckReader 边读取边校验 UTF-8 的数据流;ascii 为 true 时(已读取内容均为 ASCII)在第一个非 ASCII 字符处按所在行识别编码,
+// 不是 UTF-8 时之后的数据按 GB18030 转换(如开头为数字、编码的 GBK 文件)
+type utf8CheckReader struct {
+	br     *bufio.Reader
+	offset int64     // 已读取的字节数
+	ascii  bool      // 已读取内容均为 ASCII,编码未确定
+	src    io.Reader // 识别为 GB18030 后的数据流
+}
+
+func (r *utf8CheckReader) Read(p []byte) (n int, err error) {
+	if r.src != nil {
+		return r.src.Read(p)
+	}
+	if len(p) < utf8.UTFMax {
+		return 0, io.ErrShortBuffer
+	}
+	buf, err := r.br.Peek(len(p))
+	atEOF := errors.Is(err, io.EOF)
+	if err != nil && !atEOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+	if len(buf) == 0 {
+		return 0, err
+	}
+	if r.ascii {
+		i := 0
+		for i < len(buf) && buf[i] < utf8.RuneSelf {
+			i++
+		}
+		if i == 0 { // 第一个非 ASCII 字符,按之后的数据识别编码
+			err = r.detect()
+			if err != nil {
+				return 0, err
+			}
+			return r.Read(p)
+		}
+		buf = buf[:i]
+	} else {
+		if !atEOF {
+			buf = trimIncompleteRune(buf)
+		}
+		if i := invalidUTF8Index(buf); i >= 0 {
+			err = errors.WithMessagef(ErrorCSVEncoding, "non-utf-8 content after utf-8 content,offset:%d", r.offset+int64(i))
+			return 0, err
+		}
+	}
+	n = copy(p, buf)
+	_, err = r.br.Discard(n)
+	if err != nil {
+		return 0, err
+	}
+	r.offset += int64(n)
+	return n, nil
+}
+
+// detect 按当前行剩余数据(最多缓冲区大小)识别编码:UTF-8 或 GB18030
+func (r *utf8CheckReader) detect() (err error) {
+	window, err := r.br.Peek(r.br.Size())
+	atEOF := errors.Is(err, io.EOF)
+	if err != nil && !atEOF {
+		return err
+	}
+	if i := bytes.IndexByte(window, '\n'); i >= 0 {
+		window = window[:i]
+	} else if !atEOF {
+		window = trimIncompleteRune(window)
+	}
+	r.ascii = false
+	if !utf8.Valid(window) {
+		r.src = transform.NewReader(r.br, simplifiedchinese.GB18030.NewDecoder())
+	}
+	return nil
+}
+
+// trimIncompleteRune 去除末尾不完整的 UTF-8 字符(数据未读取完时)
+func trimIncompleteRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// invalidUTF8Index 第一个非 UTF-8 字节的位置,全部有效时返回 -1
+func invalidUTF8Index(b []byte) int {
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// detectDelimiter 按前几行识别分隔符:各行数量一致的分隔符优先,其次数量多的,默认逗号
+func detectDelimiter(data []byte) rune {
+	lines := make([]string, 0, csvSniffLines)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) >= csvSniffLines {
+			break
+		}
+	}
+	best, bestConsistent, bestCount := ',', false, 0
+	for _, delimiter := range csvDelimiters {
+		consistent, count := true, -1
+		for _, line := range lines {
+			n := countDelimiter(line, delimiter)
+			if count >= 0 && n != count {
+				consistent = false
+			}
+			count = max(count, n)
+		}
+		if count <= 0 {
+			continue
+		}
+		if (consistent && !bestConsistent) || (consistent == bestConsistent && count > bestCount) {
+			best, bestConsistent, bestCount = delimiter, consistent, count
+		}
+	}
+	return best
+}
+
+// countDelimiter 统计引号外的分隔符数量
+func countDelimiter(line string, delimiter rune) (n int) {
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delimiter && !quoted:
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,385 @@
+package excelrw
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+const DecodeTag = "excel" // 结构体标签，例如：`excel:"phone,title=手机号,format=2006-01-02,default=0"`,第一项为字段名(默认使用 json 标签名、结构体字段名),"-" 表示忽略
+
+var ErrorDecodeNotSupported = errors.New("decode type not supported")
+
+// decodeLayouts 未设置日期格式时尝试的日期格式
+var decodeLayouts = []string{
+	time.DateTime,
+	time.DateOnly,
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"2006/1/2 15:04:05",
+	"2006/1/2",
+	"2006-1-2",
+	"2006年01月02日",
+	"2006年1月2日",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04",
+}
+
+var boolValues = map[string]bool{
+	"1": true, "true": true, "yes": true, "y": true, "是": true, "✓": true, "√": true,
+	"0": false, "false": false, "no": false, "n": false, "否": false, "×": false,
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeField 结构体字段解码信息
+type decodeField struct {
+	index        []int
+	name         string
+	title        string
+	format       string
+	defaultValue string
+	validate     string
+}
+
+// makeDecodeFields 解析结构体字段标签,匿名结构体字段展开
+func makeDecodeFields(rt reflect.Type, parentIndex []int) (fields []decodeField) {
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		index := append(append([]int{}, parentIndex...), i)
+		tag, hasTag := structField.Tag.Lookup(DecodeTag)
+		if tag == "-" || !structField.IsExported() {
+			continue
+		}
+		if structField.Anonymous && !hasTag && structField.Type.Kind() == reflect.Struct {
+			fields = append(fields, makeDecodeFields(structField.Type, index)...)
+			continue
+		}
+		field := decodeField{index: index, validate: structField.Tag.Get("validate")}
+		parts := strings.Split(tag, ",")
+		field.name = strings.TrimSpace(parts[0])
+		for _, part := range parts[1:] {
+			key, value, _ := strings.Cut(part, "=")
+			switch strings.TrimSpace(key) {
+			case "title":
+				field.title = value
+			case "format":
+				field.format = value
+			case "default":
+				field.defaultValue = value
+			}
+		}
+		if field.name == "" {
+			field.name, _, _ = strings.Cut(structField.Tag.Get("json"), ",")
+		}
+		if field.name == "" || field.name == "-" {
+			field.name = structField.Name
+		}
+		if field.title == "" {
+			field.title = field.name
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func getDecodeFields[T any]() (fields []decodeField, err error) {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	if rt.Kind() != reflect.Struct {
+		err = errors.WithMessagef(ErrorDecodeNotSupported, "required struct, but got:%s", rt.String())
+		return nil, err
+	}
+	return makeDecodeFields(rt, nil), nil
+}
+
+// StructFieldMetas 由结构体标签生成字段元数据(字段名、标题、validate 标签校验规则),用于导出或按标题导入
+func StructFieldMetas[T any]() (fieldMetas defined.FieldMetas, err error) {
+	fields, err := getDecodeFields[T]()
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		fieldMetas = append(fieldMetas, defined.FieldMeta{Name: field.name, Title: field.title, Validate: field.validate})
+	}
+	return fieldMetas, nil
+}
+
+// CellError 单元格解码错误,Row 为行号(从1开始),Col 为列名称(A、B、C等)
+type CellError struct {
+	Row   int    `json:"row"`
+	Col   string `json:"col"` // 列名称,未匹配到列时为空
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Err   error  `json:"-"`
+}
+
+func (e CellError) Error() string {
+	return fmt.Sprintf("cell:%s%d,field:%s,value:%s,error:%s", e.Col, e.Row, e.Field, e.Value, e.Err.Error())
+}
+
+func (e CellError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON 序列化时输出错误信息(Err 为接口,无法直接序列化)
+func (e CellError) MarshalJSON() (b []byte, err error) {
+	type cellError CellError
+	message := ""
+	if e.Err != nil {
+		message = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		cellError
+		Message string `json:"message"`
+	}{cellError: cellError(e), Message: message})
+}
+
+// CellErrors 多个单元格解码错误
+type CellErrors []CellError
+
+func (errs CellErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// DecodeRecords 将读取的记录解码为 []T(SliceAny2string 的逆操作),空行忽略;单元格解码失败时收集错误继续解码,字段保留零值;
+// rowIndex 为第一条记录的行号,fieldMap 为读取时的列名称与字段名映射,用于错误坐标;有单元格解码错误时 err 为 CellErrors
+func DecodeRecords[T any](records []map[string]string, rowIndex int, fieldMap map[string]string) (items []T, err error) {
+	fields, err := getDecodeFields[T]()
+	if err != nil {
+		return nil, err
+	}
+	var errs CellErrors
+	colNames := make(map[string]string, len(fieldMap))
+	for colName, field := range fieldMap {
+		colNames[field] = strings.ToUpper(colName)
+	}
+	items = make([]T, 0, len(records))
+	for i, record := range records {
+		if isEmptyRecord(record) {
+			continue
+		}
+		var item T
+		rv := reflect.ValueOf(&item).Elem()
+		for _, field := range fields {
+			value, ok := record[field.name]
+			if strings.TrimSpace(value) == "" {
+				value = field.defaultValue
+			}
+			if !ok && value == "" {
+				continue
+			}
+			err := decodeValue(rv.FieldByIndex(field.index), value, field.format)
+			if err != nil {
+				errs = append(errs, CellError{Row: rowIndex + i, Col: colNames[field.name], Field: field.name, Value: value, Err: err}) // 未匹配到列时 Col 为空
+			}
+		}
+		items = append(items, item)
+	}
+	if len(errs) > 0 {
+		return items, errs
+	}
+	return items, nil
+}
+
+func isEmptyRecord(record map[string]string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeValue 将单元格值解码到字段,空值保留零值
+func decodeValue(rv reflect.Value, value string, format string) (err error) {
+	if rv.Kind() == reflect.Pointer {
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+		ptr := reflect.New(rv.Type().Elem())
+		err = decodeValue(ptr.Elem(), value, format)
+		if err != nil {
+			return err
+		}
+		rv.Set(ptr)
+		return nil
+	}
+	if rv.Kind() == reflect.String {
+		rv.SetString(value)
+		return nil
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if rv.Type() == timeType {
+		t, err := parseTime(value, format)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if rv.Addr().Type().Implements(textUnmarshalerType) { // 如 decimal.Decimal
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		b, ok := boolValues[strings.ToLower(value)]
+		if !ok {
+			return errors.Errorf("invalid bool:%s", value)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := parseInteger(value)
+		if err != nil {
+			return err
+		}
+		if rv.OverflowInt(number) {
+			return errors.Errorf("int overflow:%s", value)
+		}
+		rv.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(strings.ReplaceAll(value, ",", ""), 10, 64)
+		if err != nil {
+			n, err := parseInteger(value)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return errors.Errorf("invalid unsigned integer:%s", value)
+			}
+			number = uint64(n)
+		}
+		if rv.OverflowUint(number) {
+			return errors.Errorf("uint overflow:%s", value)
+		}
+		rv.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := parseFloat(value)
+		if err != nil {
+			return err
+		}
+		if rv.OverflowFloat(number) {
+			return errors.Errorf("float overflow:%s", value)
+		}
+		rv.SetFloat(number)
+	default:
+		err = errors.WithMessagef(ErrorDecodeNotSupported, "type:%s", rv.Type().String())
+		return err
+	}
+	return nil
+}
+
+// parseFloat 解析数字,兼容千分位、百分比
+func parseFloat(value string) (number float64, err error) {
+	value = strings.ReplaceAll(value, ",", "")
+	percent := strings.HasSuffix(value, "%")
+	number, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid number:%s", value)
+	}
+	if percent {
+		number /= 100
+	}
+	return number, nil
+}
+
+// parseInteger 解析整数,兼容千分位及小数部分为0的数字(如 12.0)
+func parseInteger(value string) (number int64, err error) {
+	value = strings.ReplaceAll(value, ",", "")
+	if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return number, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) || f > math.MaxInt64 || f < math.MinInt64 {
+		return 0, errors.Errorf("invalid integer:%s", value)
+	}
+	return int64(f), nil
+}
+
+// parseTime 解析日期,format 不为空时按格式解析,否则依次尝试 excel 日期序列号、常用日期格式;
+// excel 日期不含时区,与字符串日期一致按本地时区解析
+func parseTime(value string, format string) (t time.Time, err error) {
+	if format != "" {
+		t, err = time.ParseInLocation(format, value, time.Local)
+		if err != nil {
+			return t, errors.Errorf("invalid date:%s,format:%s", value, format)
+		}
+		return t, nil
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		t, err = excelize.ExcelDateToTime(serial, false)
+		if err != nil {
+			return t, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local), nil
+	}
+	for _, layout := range decodeLayouts {
+		t, err = time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return t, errors.Errorf("invalid date:%s", value)
+}
+
+// ReadStructs 按结构体标签(标题)匹配标题行读取数据并解码为 []T,单元格解码错误以 CellErrors 返回(同时返回全部数据)
+func ReadStructs[T any](f *excelize.File, sheet string, options HeaderOptions) (items []T, err error) {
+	err = ReadStructsStream(context.Background(), f, sheet, options, 0, func(batch []T) (err error) {
+		items = append(items, batch...)
+		return nil
+	})
+	return items, err
+}
+
+// ReadStructsStream 流式读取并解码为 []T,每批数据回调一次,单元格解码错误在读取完成后以 CellErrors 返回
+func ReadStructsStream[T any](ctx context.Context, f *excelize.File, sheet string, options HeaderOptions, batchSize int, fn func(items []T) (err error)) (err error) {
+	fieldMetas, err := StructFieldMetas[T]()
+	if err != nil {
+		return err
+	}
+	reader := NewExcelReader().WithRawCellValue(true)
+	fieldMap, rowIndex, err := reader.MatchHeader(f, sheet, fieldMetas, options)
+	if err != nil {
+		return err
+	}
+	var cellErrs CellErrors
+	err = reader.ReadStream(ctx, f, sheet, fieldMap, rowIndex, batchSize, func(records []map[string]string) (err error) {
+		items, err := DecodeRecords[T](records, rowIndex, fieldMap)
+		rowIndex += len(records)
+		var errs CellErrors
+		if errors.As(err, &errs) {
+			cellErrs = append(cellErrs, errs...)
+		} else if err != nil {
+			return err
+		}
+		return fn(items)
+	})
+	if err != nil {
+		return err
+	}
+	if len(cellErrs) > 0 {
+		return cellErrs
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package excelrw
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+const HeaderScanRows_default = 10
+
+// HeaderOptions 按标题读取选项
+type HeaderOptions struct {
+	HeaderRow  int `json:"headerRow"`  // 标题行行号(从1开始),为0时在前 ScanRows 行中自动定位(匹配字段最多的行)
+	HeaderRows int `json:"headerRows"` // 标题行行数(多级标题),为0时使用字段元数据的标题层级
+	ScanRows   int `json:"scanRows"`   // 自动定位标题行时扫描的行数,默认：10
+}
+
+func (o HeaderOptions) getScanRows() int {
+	if o.ScanRows <= 0 {
+		return HeaderScanRows_default
+	}
+	return o.ScanRows
+}
+
+// MatchHeader 定位标题行并按标题匹配字段(与导出使用相同的字段元数据,标题=>字段名),返回 Read、ReadStream 使用的列名称与字段名映射及数据开始行号
+func (instance *_ExcelReader) MatchHeader(f *excelize.File, sheet string, fieldMetas defined.FieldMetas, options HeaderOptions) (fieldMap map[string]string, rowIndex int, err error) {
+	return matchHeader(sheet, fieldMetas, options, func(n int) (rows [][]string, err error) {
+		return instance.readTopRows(f, sheet, n)
+	})
+}
+
+// matchHeader 定位标题行并按标题匹配字段,readTopRows 读取前 n 行(xlsx、csv 共用),sheet 只用于错误信息
+func matchHeader(sheet string, fieldMetas defined.FieldMetas, options HeaderOptions, readTopRows func(n int) (rows [][]string, err error)) (fieldMap map[string]string, rowIndex int, err error) {
+	headerRows := options.HeaderRows
+	if headerRows <= 0 {
+		headerRows = fieldMetas.HeaderDepth()
+	}
+	startRows := []int{options.HeaderRow}
+	if options.HeaderRow <= 0 {
+		startRows = make([]int, 0, options.getScanRows())
+		for row := 1; row <= options.getScanRows(); row++ {
+			startRows = append(startRows, row)
+		}
+	}
+	scanRows := startRows[len(startRows)-1] + headerRows - 1
+	rows, err := readTopRows(scanRows)
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		bestMatched   map[int]int
+		bestMissing   defined.FieldMetas
+		bestHeaderRow int
+	)
+	for _, headerRow := range startRows {
+		if headerRow > len(rows) {
+			break
+		}
+		endRow := min(headerRow+headerRows-1, len(rows))
+		columns := defined.MakeHeaderColumns(rows[headerRow-1 : endRow])
+		matched, missing := fieldMetas.MatchColumns(columns)
+		if len(matched) > len(bestMatched) {
+			bestMatched, bestMissing, bestHeaderRow = matched, missing, headerRow
+		}
+	}
+	if len(bestMatched) == 0 {
+		err = errors.WithMessagef(defined.ErrorHeaderNotFound, "sheet:%s", sheet)
+		return nil, 0, err
+	}
+	if len(bestMissing) > 0 {
+		titles := make([]string, 0, len(bestMissing))
+		for _, fieldMeta := range bestMissing {
+			titles = append(titles, fieldMeta.Title)
+		}
+		err = errors.WithMessagef(defined.ErrorHeaderMissing, "sheet:%s,row:%d,columns:%s", sheet, bestHeaderRow, strings.Join(titles, ","))
+		return nil, 0, err
+	}
+	fieldMap = make(map[string]string, len(bestMatched))
+	for fieldIndex, col := range bestMatched {
+		colName, err := excelize.ColumnNumberToName(col + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		fieldMap[colName] = fieldMetas[fieldIndex].Name
+	}
+	rowIndex = bestHeaderRow + headerRows
+	return fieldMap, rowIndex, nil
+}
+
+// readTopRows 流式读取表单前 n 行(不加载全部行)
+func (instance *_ExcelReader) readTopRows(f *excelize.File, sheet string, n int) (output [][]string, err error) {
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for len(output) < n && rows.Next() {
+		row, err := rows.Columns(instance.cellOptions())
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, row)
+	}
+	if err = rows.Error(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// ReadByHeader 按标题行匹配列读取全部数据(列顺序调整、插入其它列不影响读取)
+func (instance *_ExcelReader) ReadByHeader(f *excelize.File, sheet string, fieldMetas defined.FieldMetas, options HeaderOptions) (records []map[string]string, err error) {
+	fieldMap, rowIndex, err := instance.MatchHeader(f, sheet, fieldMetas, options)
+	if err != nil {
+		return nil, err
+	}
+	return instance.Read(f, sheet, fieldMap, rowIndex, false)
+}
+
+// ReadStreamByHeader 按标题行匹配列流式读取数据
+func (instance *_ExcelReader) ReadStreamByHeader(ctx context.Context, f *excelize.File, sheet string, fieldMetas defined.FieldMetas, options HeaderOptions, batchSize int, fn ReadBatchFn) (err error) {
+	fieldMap, rowIndex, err := instance.MatchHeader(f, sheet, fieldMetas, options)
+	if err != nil {
+		return err
+	}
+	return instance.ReadStream(ctx, f, sheet, fieldMap, rowIndex, batchSize, fn)
+}
@@ -0,0 +1,80 @@
+package excelrw
+
+import (
+	"context"
+	"iter"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+const ReadBatchSize_default = 1000
+
+// ReadBatchFn 批量处理读取的记录,返回错误时停止读取
+type ReadBatchFn func(records []map[string]string) (err error)
+
+var errStopRead = errors.New("stop read")
+
+// ReadStream 按行流式读取表单(不一次性加载全部行),每 batchSize 行回调一次,fieldMap、rowIndex 与 Read 相同;不展开合并单元格
+func (instance *_ExcelReader) ReadStream(ctx context.Context, f *excelize.File, sheet string, fieldMap map[string]string, rowIndex int, batchSize int, fn ReadBatchFn) (err error) {
+	if batchSize <= 0 {
+		batchSize = ReadBatchSize_default
+	}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	fieldMap = normalizeFieldMap(fieldMap)
+	batch := make([]map[string]string, 0, batchSize)
+	for index := 0; rows.Next(); index++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if index < rowIndex-1 { // 从指定行开始读取
+			continue
+		}
+		row, err := rows.Columns(instance.cellOptions())
+		if err != nil {
+			return err
+		}
+		record, err := makeRecord(row, fieldMap)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, record)
+		if len(batch) < batchSize {
+			continue
+		}
+		err = fn(batch)
+		if err != nil {
+			return err
+		}
+		batch = make([]map[string]string, 0, batchSize)
+	}
+	if err = rows.Error(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		err = fn(batch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSeq 流式读取表单的迭代器版本,每次迭代返回一批记录,读取出错时返回错误后结束迭代
+func (instance *_ExcelReader) ReadSeq(ctx context.Context, f *excelize.File, sheet string, fieldMap map[string]string, rowIndex int, batchSize int) iter.Seq2[[]map[string]string, error] {
+	return func(yield func([]map[string]string, error) bool) {
+		err := instance.ReadStream(ctx, f, sheet, fieldMap, rowIndex, batchSize, func(records []map[string]string) (err error) {
+			if !yield(records, nil) {
+				return errStopRead
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopRead) {
+			yield(nil, err)
+		}
+	}
+}
@@ -1,13 +1,23 @@
 package excelrw_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf16"
 
 	"github.com/stretchr/testify/require"
 	"github.com/suifengpiao14/excelrw"
+	"github.com/suifengpiao14/excelrw/defined"
 	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 func TestRead(t *testing.T) {
@@ -31,3 +41,464 @@ func TestRead(t *testing.T) {
 	require.NoError(t, err)
 	fmt.Println(string(b))
 }
+
+func TestReadStream(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stream.xlsx")
+	fd := excelize.NewFile()
+	sw, err := fd.NewStreamWriter(excelrw.SheetDefault)
+	require.NoError(t, err)
+	require.NoError(t, sw.SetRow("A1", []any{"ID", "名称"}))
+	for i := 1; i <= 2500; i++ {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, err)
+		require.NoError(t, sw.SetRow(cell, []any{i, fmt.Sprintf("name%d", i)}))
+	}
+	require.NoError(t, sw.Flush())
+	require.NoError(t, fd.SaveAs(filename))
+	require.NoError(t, fd.Close())
+
+	fd, err = excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	reader := excelrw.NewExcelReader()
+	fieldMap := map[string]string{"a": "id", "B": "name"}
+	sizes := make([]int, 0)
+	err = reader.ReadStream(context.Background(), fd, excelrw.SheetDefault, fieldMap, 2, 1000, func(records []map[string]string) (err error) {
+		sizes = append(sizes, len(records))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1000, 1000, 500}, sizes)
+
+	for records, err := range reader.ReadSeq(context.Background(), fd, excelrw.SheetDefault, fieldMap, 2, 10) {
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"id": "1", "name": "name1"}, records[0])
+		break
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var readErr error
+	for _, err := range reader.ReadSeq(ctx, fd, excelrw.SheetDefault, fieldMap, 2, 10) {
+		readErr = err
+	}
+	require.ErrorIs(t, readErr, context.Canceled)
+}
+
+func TestReadByHeader(t *testing.T) {
+	reader := excelrw.NewExcelReader()
+	t.Run("alias", func(t *testing.T) {
+		fd := excelize.NewFile()
+		defer fd.Close()
+		sheet := fd.GetSheetName(0)
+		require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"订单导出"}))
+		require.NoError(t, fd.SetSheetRow(sheet, "A2", &[]any{" 手机号 ", "备注", "NAME*"}))
+		require.NoError(t, fd.SetSheetRow(sheet, "A3", &[]any{"138", "x", "a"}))
+		fieldMetas := defined.FieldMetas{
+			{Name: "name", Title: "Name", Required: true},
+			{Name: "phone", Title: "电话", Aliases: []string{"手机号"}},
+			{Name: "amount", Title: "金额"},
+		}
+		records, err := reader.ReadByHeader(fd, sheet, fieldMetas, excelrw.HeaderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []map[string]string{{"name": "a", "phone": "138"}}, records)
+
+		fieldMetas = append(fieldMetas, defined.FieldMeta{Name: "status", Title: "状态", Required: true})
+		_, err = reader.ReadByHeader(fd, sheet, fieldMetas, excelrw.HeaderOptions{})
+		require.ErrorIs(t, err, defined.ErrorHeaderMissing)
+		require.Contains(t, err.Error(), "状态")
+
+		_, err = reader.ReadByHeader(fd, sheet, defined.FieldMetas{{Name: "id", Title: "ID"}}, excelrw.HeaderOptions{})
+		require.ErrorIs(t, err, defined.ErrorHeaderNotFound)
+	})
+	t.Run("group", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "group.xlsx")
+		fieldMetas := defined.FieldMetas{
+			{Name: "amount", Title: "金额"},
+			{Name: "buyerName", Title: "姓名", Group: []string{"买家", "姓名"}},
+			{Name: "sellerName", Title: "姓名", Group: []string{"卖家", "姓名"}},
+		}
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas)
+		require.NoError(t, ecw.WriteData([]map[string]string{{"amount": "10", "buyerName": "a", "sellerName": "b"}}))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		reordered := defined.FieldMetas{fieldMetas[2], fieldMetas[1], fieldMetas[0]}
+		records, err := reader.ReadByHeader(fd, excelrw.SheetDefault, reordered, excelrw.HeaderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []map[string]string{{"amount": "10", "buyerName": "a", "sellerName": "b"}}, records)
+	})
+}
+
+type importOrder struct {
+	ID        int64      `excel:"id,title=订单ID"`
+	Amount    float64    `excel:"amount,title=金额"`
+	Paid      bool       `excel:"paid,title=已支付"`
+	Quantity  uint8      `excel:"quantity,title=数量,default=1"`
+	CreatedAt time.Time  `excel:"createdAt,title=创建时间"`
+	PaidAt    *time.Time `excel:"paidAt,title=支付日期,format=2006/01/02"`
+	Remark    string     `json:"remark"`
+	Ignored   string     `excel:"-"`
+}
+
+func TestReadStructs(t *testing.T) {
+	fd := excelize.NewFile()
+	defer fd.Close()
+	sheet := fd.GetSheetName(0)
+	dateStyle, err := fd.NewStyle(&excelize.Style{NumFmt: 22})
+	require.NoError(t, err)
+	require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"remark", "订单ID", "金额", "已支付", "数量", "创建时间", "支付日期"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A2", &[]any{"a", "9007199254740993", "1,234.5", "是", "", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "2024/02/03"}))
+	require.NoError(t, fd.SetCellStyle(sheet, "F2", "F2", dateStyle))
+	require.NoError(t, fd.SetSheetRow(sheet, "A3", &[]any{}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A4", &[]any{"b", "x", "10%", "maybe", "300", "2024-01-02", ""}))
+
+	orders, err := excelrw.ReadStructs[importOrder](fd, sheet, excelrw.HeaderOptions{})
+	var cellErrs excelrw.CellErrors
+	require.ErrorAs(t, err, &cellErrs)
+	require.Len(t, orders, 2)
+
+	order := orders[0]
+	require.Equal(t, int64(9007199254740993), order.ID)
+	require.Equal(t, 1234.5, order.Amount)
+	require.True(t, order.Paid)
+	require.Equal(t, uint8(1), order.Quantity)
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), order.CreatedAt) // excel 日期与字符串日期均按本地时区解析
+	require.NotNil(t, order.PaidAt)
+	require.Equal(t, "2024-02-03", order.PaidAt.Format(time.DateOnly))
+	require.Equal(t, "a", order.Remark)
+
+	require.Equal(t, 0.1, orders[1].Amount)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), orders[1].CreatedAt)
+	require.Nil(t, orders[1].PaidAt)
+	cells := make([]string, 0, len(cellErrs))
+	for _, cellErr := range cellErrs {
+		cells = append(cells, fmt.Sprintf("%s%d", cellErr.Col, cellErr.Row))
+	}
+	require.Equal(t, []string{"B4", "D4", "E4"}, cells)
+}
+
+func TestReadAndValidate(t *testing.T) {
+	fd := excelize.NewFile()
+	defer fd.Close()
+	sheet := fd.GetSheetName(0)
+	require.NoError(t, fd.SetSheetRow(sheet, "A1", &[]any{"邮箱", "状态", "金额"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A2", &[]any{"a@example.com", "待使用", "10"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A3", &[]any{"bad", "未知", "-1"}))
+	require.NoError(t, fd.SetSheetRow(sheet, "A4", &[]any{"", "已使用", "1,000"}))
+	fieldMetas := defined.FieldMetas{
+		{Name: "email", Title: "邮箱", Validate: "required,email"},
+		{Name: "status", Title: "状态", Validate: "oneof=待使用 已使用"},
+		{Name: "amount", Title: "金额", Type: defined.FieldType_number, Validate: "gte=0"},
+	}
+	reader := excelrw.NewExcelReader()
+	records, errorFile, err := reader.ReadAndValidate(fd, sheet, fieldMetas, excelrw.HeaderOptions{})
+	var cellErrs excelrw.CellErrors
+	require.ErrorAs(t, err, &cellErrs)
+	require.Len(t, records, 3)
+	cells := make([]string, 0, len(cellErrs))
+	for _, cellErr := range cellErrs {
+		cells = append(cells, fmt.Sprintf("%s%d", cellErr.Col, cellErr.Row))
+	}
+	require.Equal(t, []string{"A3", "B3", "C3", "A4"}, cells)
+	require.NotNil(t, errorFile)
+
+	annotated, err := excelize.OpenReader(errorFile)
+	require.NoError(t, err)
+	defer annotated.Close()
+	title, err := annotated.GetCellValue(sheet, "D1")
+	require.NoError(t, err)
+	require.Equal(t, excelrw.ErrorColumnTitle, title)
+	msg, err := annotated.GetCellValue(sheet, "D3")
+	require.NoError(t, err)
+	require.Equal(t, "邮箱不是有效的邮箱地址;状态只能是：待使用 已使用;金额不能小于0", msg)
+	msg, err = annotated.GetCellValue(sheet, "D2")
+	require.NoError(t, err)
+	require.Empty(t, msg)
+	styleID, err := annotated.GetCellStyle(sheet, "B3")
+	require.NoError(t, err)
+	style, err := annotated.GetStyle(styleID)
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.TrimPrefix(excelrw.ErrorCellFill, "#")}, style.Fill.Color)
+
+	original, err := fd.GetCellValue(sheet, "D1") // 不修改上传文件
+	require.NoError(t, err)
+	require.Empty(t, original)
+
+	t.Run("numberRequiredAndUnmatched", func(t *testing.T) {
+		records := []map[string]string{{"amount": "0"}, {"amount": "", "remark": "x"}}
+		fieldMetas := defined.FieldMetas{
+			{Name: "amount", Title: "金额", Type: defined.FieldType_number, Validate: "required,gte=0"},
+			{Name: "name", Title: "名称", Validate: "required"}, // 未匹配到列
+		}
+		err := excelrw.ValidateRecords(records, 2, fieldMetas, map[string]string{"a": "amount"})
+		var cellErrs excelrw.CellErrors
+		require.ErrorAs(t, err, &cellErrs)
+		require.Len(t, cellErrs, 3)
+		require.Equal(t, "", cellErrs[0].Col) // 0 不视为空值
+		require.Equal(t, "name", cellErrs[0].Field)
+		require.Equal(t, "A", cellErrs[1].Col)
+		require.Equal(t, 3, cellErrs[1].Row)
+		require.EqualError(t, cellErrs[1].Err, "金额不能为空")
+
+		b, err := json.Marshal(cellErrs[1])
+		require.NoError(t, err)
+		require.JSONEq(t, `{"row":3,"col":"A","field":"amount","value":"","message":"金额不能为空"}`, string(b))
+
+		annotated, err := reader.AnnotateErrors(fd, sheet, 1, cellErrs)
+		require.NoError(t, err)
+		annotatedFd, err := excelize.OpenReader(annotated)
+		require.NoError(t, err)
+		defer annotatedFd.Close()
+		msg, err := annotatedFd.GetCellValue(sheet, "D2") // 未匹配到列的错误只写入错误信息列
+		require.NoError(t, err)
+		require.Equal(t, "名称不能为空", msg)
+	})
+}
+
+func TestOpenUploadCSV(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("名称;金额;备注\n张三;1,000;\"a;b\"\n李四;20;\n")
+	require.NoError(t, err)
+	fieldMetas := defined.FieldMetas{{Name: "name", Title: "名称"}, {Name: "amount", Title: "金额"}, {Name: "remark", Title: "备注"}}
+	reader := excelrw.NewExcelReader()
+	fd, err := excelrw.OpenUpload("订单.csv", strings.NewReader(gbk))
+	require.NoError(t, err)
+	records, err := reader.ReadByHeader(fd, excelrw.SheetDefault, fieldMetas, excelrw.HeaderOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]string{
+		{"name": "张三", "amount": "1,000", "remark": "a;b"},
+		{"name": "李四", "amount": "20"}, // 行尾空单元格与 xlsx 读取一致,不输出
+	}, records)
+
+	tsv := "\xEF\xBB\xBF名称\t金额\n王五\t007\n"
+	fd, err = excelrw.OpenUpload("upload.tsv", strings.NewReader(tsv))
+	require.NoError(t, err)
+	records, err = reader.Read(fd, excelrw.SheetDefault, map[string]string{"a": "name", "b": "amount"}, 2, false)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]string{{"name": "王五", "amount": "007"}}, records)
+
+	t.Run("stream", func(t *testing.T) {
+		cr, err := excelrw.NewCSVReader(strings.NewReader(gbk), 0)
+		require.NoError(t, err)
+		batches := make([][]map[string]string, 0)
+		err = cr.ReadStreamByHeader(context.Background(), fieldMetas, excelrw.HeaderOptions{}, 1, func(records []map[string]string) (err error) {
+			batches = append(batches, records)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, [][]map[string]string{ // 与 xlsx 流式读取返回相同的记录
+			{{"name": "张三", "amount": "1,000", "remark": "a;b"}},
+			{{"name": "李四", "amount": "20"}},
+		}, batches)
+		_, _, err = cr.MatchHeader(fieldMetas, excelrw.HeaderOptions{})
+		require.ErrorIs(t, err, excelrw.ErrorCSVAlreadyRead)
+
+		data := "名称,金额\n" + strings.Repeat("很长的名称很长的名称,1\n", 10000) // 超过预读长度,不完整的最后一行不参与编码识别
+		cr, err = excelrw.NewCSVReader(strings.NewReader(data), 0)
+		require.NoError(t, err)
+		total := 0
+		err = cr.ReadStreamByHeader(context.Background(), fieldMetas[:2], excelrw.HeaderOptions{}, 0, func(records []map[string]string) (err error) {
+			for _, record := range records {
+				require.Equal(t, map[string]string{"name": "很长的名称很长的名称", "amount": "1"}, record)
+			}
+			total += len(records)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 10000, total)
+	})
+
+	t.Run("asciiHead", func(t *testing.T) {
+		fieldMetas := defined.FieldMetas{{Name: "id", Title: "id"}, {Name: "name", Title: "name"}}
+		ascii := "id,name\n" + strings.Repeat("1234567890,abc\n", 5000) // 超过预读长度的 ASCII
+		tail, err := simplifiedchinese.GBK.NewEncoder().String("2,张三\n")
+		require.NoError(t, err)
+		cr, err := excelrw.NewCSVReader(strings.NewReader(ascii+tail), 0)
+		require.NoError(t, err)
+		var last map[string]string
+		err = cr.ReadStreamByHeader(context.Background(), fieldMetas, excelrw.HeaderOptions{}, 0, func(records []map[string]string) (err error) {
+			last = records[len(records)-1]
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"id": "2", "name": "张三"}, last) // 开头为 ASCII 的 GBK 文件
+
+		cr, err = excelrw.NewCSVReader(strings.NewReader(ascii+"1,中文\n"+tail), 0)
+		require.NoError(t, err)
+		err = cr.ReadStreamByHeader(context.Background(), fieldMetas, excelrw.HeaderOptions{}, 0, func(records []map[string]string) (err error) {
+			return nil
+		})
+		require.ErrorIs(t, err, excelrw.ErrorCSVEncoding) // UTF-8 之后出现 GBK 内容
+	})
+
+	require.Equal(t, excelrw.FileFormat_xlsx, excelrw.DetectFileFormat("upload.csv", []byte("PK\x03\x04")))
+	require.Equal(t, excelrw.FileFormat_xls, excelrw.DetectFileFormat("upload.xls", nil))
+}
+
+// biffRecord 生成 BIFF 记录
+func biffRecord(typ uint16, parts ...[]byte) []byte {
+	data := bytes.Join(parts, nil)
+	record := binary.LittleEndian.AppendUint16(nil, typ)
+	record = binary.LittleEndian.AppendUint16(record, uint16(len(data)))
+	return append(record, data...)
+}
+
+func le16(values ...int) (b []byte) {
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// biffString 生成 XLUnicodeString(UTF-16)
+func biffString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := append(le16(len(units)), 1)
+	return append(b, le16(toInts(units)...)...)
+}
+
+func toInts(units []uint16) (values []int) {
+	for _, u := range units {
+		values = append(values, int(u))
+	}
+	return values
+}
+
+// makeCFB 生成只包含 Workbook 流的 OLE 复合文档(512 字节扇区,流不小于 4096 字节避免使用短流)
+func makeCFB(stream []byte) []byte {
+	const sectorSize, endOfChain, freeSect, noStream = 512, 0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFF
+	if len(stream) < 4096 {
+		stream = append(stream, make([]byte, 4096-len(stream))...)
+	}
+	streamSectors := (len(stream) + sectorSize - 1) / sectorSize
+	stream = append(stream, make([]byte, streamSectors*sectorSize-len(stream))...)
+
+	header := make([]byte, sectorSize)
+	copy(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	copy(header[24:], le16(0x003E, 0x0003, 0xFFFE, 0x0009, 0x0006))
+	copy(header[44:], le32(1))          // FAT 扇区数
+	copy(header[48:], le32(1))          // 目录起始扇区
+	copy(header[56:], le32(0x1000))     // 短流大小上限
+	copy(header[60:], le32(endOfChain)) // 短流 FAT 起始扇区
+	copy(header[68:], le32(endOfChain)) // DIFAT 起始扇区
+	for i := 0; i < 109; i++ {
+		copy(header[76+i*4:], le32(freeSect))
+	}
+	copy(header[76:], le32(0)) // FAT 位于扇区0
+
+	fat := make([]byte, 0, sectorSize)
+	fat = append(fat, le32(0xFFFFFFFD)...) // FAT 扇区
+	fat = append(fat, le32(endOfChain)...) // 目录扇区
+	for i := 0; i < streamSectors; i++ {
+		next := uint32(endOfChain)
+		if i < streamSectors-1 {
+			next = uint32(i + 3)
+		}
+		fat = append(fat, le32(next)...)
+	}
+	for len(fat) < sectorSize {
+		fat = append(fat, le32(freeSect)...)
+	}
+
+	dirEntry := func(name string, typ byte, child uint32, start uint32, size int) []byte {
+		entry := make([]byte, 128)
+		units := utf16.Encode([]rune(name))
+		copy(entry, le16(toInts(units)...))
+		copy(entry[64:], le16((len(units)+1)*2))
+		entry[66], entry[67] = typ, 1
+		copy(entry[68:], le32(noStream))
+		copy(entry[72:], le32(noStream))
+		copy(entry[76:], le32(child))
+		copy(entry[116:], le32(start))
+		copy(entry[120:], le32(uint32(size)))
+		return entry
+	}
+	dir := bytes.Join([][]byte{
+		dirEntry("Root Entry", 5, 1, endOfChain, 0),
+		dirEntry("Workbook", 2, noStream, 2, len(stream)),
+		dirEntry("", 0, noStream, 0, 0),
+		dirEntry("", 0, noStream, 0, 0),
+	}, nil)
+	return bytes.Join([][]byte{header, fat, dir, stream}, nil)
+}
+
+func TestOpenUploadXls(t *testing.T) {
+	xf := func(numFmt int) []byte { return biffRecord(0x00E0, le16(0, numFmt), make([]byte, 16)) }
+	sheetName := utf16.Encode([]rune("订单"))
+	boundSheet := func(offset uint32) []byte {
+		return biffRecord(0x0085, le32(offset), []byte{0, 0, byte(len(sheetName)), 1}, le16(toInts(sheetName)...))
+	}
+	sst := biffRecord(0x00FC, le32(4), le32(4),
+		biffString("名称"),
+		le16(5), []byte{0}, []byte("Alice"),
+		le16(4), []byte{0}, []byte("AB"), // 字符串跨 CONTINUE 记录
+	)
+	globals := bytes.Join([][]byte{
+		biffRecord(0x0809, le16(0x0600, 0x0005), make([]byte, 12)),
+		biffRecord(0x041E, le16(164), le16(10), []byte{0}, []byte("yyyy/mm/dd")),
+		xf(0), xf(164), xf(31),
+		sst,
+		biffRecord(0x003C, []byte{1}, le16(toInts(utf16.Encode([]rune("中文")))...), le16(2), []byte{0}), // 字符串头部在记录末尾
+		biffRecord(0x003C, []byte{0}, []byte("XY")),                                                    // 字符数据以压缩标志开头
+	}, nil)
+	offset := uint32(len(globals) + len(boundSheet(0)) + 4)
+	rk := func(v uint32) []byte { return le32(v<<2 | 0x02) }
+	sheet := bytes.Join([][]byte{
+		biffRecord(0x0809, le16(0x0600, 0x0010), make([]byte, 12)),
+		biffRecord(0x00FD, le16(0, 0, 0), le32(0)),
+		biffRecord(0x0204, le16(0, 1, 0), biffString("金额")),
+		biffRecord(0x0204, le16(0, 2, 0), biffString("日期")),
+		biffRecord(0x00FD, le16(1, 0, 0), le32(1)),
+		biffRecord(0x027E, le16(1, 1, 0), le32(123450<<2|0x03)), // 1234.5
+		biffRecord(0x0203, le16(1, 2, 1), binary.LittleEndian.AppendUint64(nil, math.Float64bits(45292))),
+		biffRecord(0x00FD, le16(2, 0, 0), le32(2)),
+		biffRecord(0x00BD, le16(2, 1), le16(0), rk(7), le16(2), rk(45293), le16(2)),
+		biffRecord(0x0204, le16(3, 0, 0), biffString("合并")),
+		biffRecord(0x0006, le16(3, 1, 0), binary.LittleEndian.AppendUint64(nil, math.Float64bits(3.5)), make([]byte, 6)),
+		biffRecord(0x0205, le16(4, 1, 0), []byte{1, 0}),
+		biffRecord(0x00FD, le16(5, 0, 0), le32(3)),
+		biffRecord(0x0006, le16(5, 1, 0), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, make([]byte, 6)), // 字符串结果
+		biffRecord(0x04BC, make([]byte, 10)),                                                     // 共享公式记录在 STRING 之前
+		biffRecord(0x0207, biffString("公式")),
+		biffRecord(0x00E5, le16(1), le16(3, 4, 0, 0)),
+		biffRecord(0x000A),
+	}, nil)
+	stream := bytes.Join([][]byte{globals, boundSheet(offset), biffRecord(0x000A), sheet}, nil)
+
+	fd, err := excelrw.OpenUpload("订单.xls", bytes.NewReader(makeCFB(stream)))
+	require.NoError(t, err)
+	defer fd.Close()
+	require.Equal(t, []string{"订单"}, fd.GetSheetList())
+	reader := excelrw.NewExcelReader()
+	records, err := reader.Read(fd, "订单", map[string]string{"a": "name", "b": "amount", "c": "date"}, 2, true)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]string{
+		{"name": "Alice", "amount": "1234.5", "date": "2024/01/01"},
+		{"name": "AB中文", "amount": "7", "date": "01-02-24"},
+		{"name": "合并", "amount": "3.5"},
+		{"name": "合并", "amount": "TRUE"},
+		{"name": "XY", "amount": "公式"},
+	}, records)
+
+	records, err = reader.ReadByHeader(fd, "订单", defined.FieldMetas{{Name: "date", Title: "日期"}}, excelrw.HeaderOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "2024/01/01", records[0]["date"])
+
+	t.Run("oddUTF16", func(t *testing.T) {
+		sheet := bytes.Join([][]byte{
+			biffRecord(0x0809, le16(0x0600, 0x0010), make([]byte, 12)),
+			biffRecord(0x0204, le16(0, 0, 0), le16(5), []byte{0x01, 'a', 0x00, 'b'}), // UTF-16 字符只剩一个字节
+			biffRecord(0x000A),
+		}, nil)
+		stream := bytes.Join([][]byte{globals, boundSheet(offset), biffRecord(0x000A), sheet}, nil)
+		_, err := excelrw.OpenUpload("订单.xls", bytes.NewReader(makeCFB(stream)))
+		require.ErrorIs(t, err, excelrw.ErrorXlsCorrupted)
+		require.ErrorContains(t, err, "odd byte in utf-16 string")
+	})
+}
@@ -0,0 +1,227 @@
+package excelrw
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ErrorColumnTitle = "错误信息"    // 错误文件追加的错误信息列标题
+	ErrorCellFill    = "#FFC7CE" // 错误文件中校验失败单元格背景色
+)
+
+var recordValidator = validator.New()
+
+// validateMessages 校验规则错误提示,%s 为规则参数
+var validateMessages = map[string]string{
+	"required": "不能为空",
+	"email":    "不是有效的邮箱地址",
+	"url":      "不是有效的网址",
+	"numeric":  "必须是数字",
+	"number":   "必须是数字",
+	"oneof":    "只能是：%s",
+	"len":      "长度必须是%s",
+	"min":      "不能小于%s",
+	"max":      "不能大于%s",
+	"gt":       "必须大于%s",
+	"gte":      "不能小于%s",
+	"lt":       "必须小于%s",
+	"lte":      "不能大于%s",
+	"eq":       "必须等于%s",
+	"ne":       "不能等于%s",
+	"datetime": "日期格式必须是%s",
+}
+
+// validateMessage 校验错误提示
+func validateMessage(fieldErr validator.FieldError) string {
+	msg, ok := validateMessages[fieldErr.Tag()]
+	if !ok {
+		return fmt.Sprintf("不满足规则：%s", fieldErr.Tag())
+	}
+	if strings.Contains(msg, "%s") {
+		return fmt.Sprintf(msg, fieldErr.Param())
+	}
+	return msg
+}
+
+// ValidateRecords 按字段元数据的校验规则(Validate)校验记录,空行忽略,返回全部校验失败的单元格(CellErrors);
+// rowIndex 为第一条记录的行号,fieldMap 为读取时的列名称与字段名映射,用于错误坐标
+func ValidateRecords(records []map[string]string, rowIndex int, fieldMetas defined.FieldMetas, fieldMap map[string]string) (err error) {
+	colNames := make(map[string]string, len(fieldMap))
+	for colName, field := range fieldMap {
+		colNames[field] = strings.ToUpper(colName)
+	}
+	var errs CellErrors
+	for i, record := range records {
+		if isEmptyRecord(record) {
+			continue
+		}
+		for _, fieldMeta := range fieldMetas {
+			if fieldMeta.Validate == "" {
+				continue
+			}
+			value := record[fieldMeta.Name]
+			err = validateValue(fieldMeta, value)
+			if err == nil {
+				continue
+			}
+			errs = append(errs, CellError{Row: rowIndex + i, Col: colNames[fieldMeta.Name], Field: fieldMeta.Name, Value: value, Err: err}) // 未匹配到列时 Col 为空
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateValue 校验单元格值,type=number 时 required、omitempty 按原始值校验,其余规则按数字校验(gte、lte 等比较数值而不是长度)
+func validateValue(fieldMeta defined.FieldMeta, value string) (err error) {
+	if fieldMeta.Type != defined.FieldType_number {
+		return validateVar(fieldMeta, value, fieldMeta.Validate)
+	}
+	valueRules, numberRules := splitNumberRules(fieldMeta.Validate)
+	if valueRules != "" {
+		err = validateVar(fieldMeta, value, valueRules)
+		if err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(value) == "" || numberRules == "" {
+		return nil
+	}
+	number, ok := defined.ParseNumber(value)
+	if !ok {
+		return errors.Errorf("%s必须是数字", fieldMeta.Title)
+	}
+	return validateVar(fieldMeta, number, numberRules)
+}
+
+// splitNumberRules 拆分数字字段的校验规则,valueRules 为判断是否为空的规则(required、omitempty 等),0 不能视为空值
+func splitNumberRules(validate string) (valueRules string, numberRules string) {
+	var values, numbers []string
+	for _, rule := range strings.Split(validate, ",") {
+		if strings.HasPrefix(rule, "required") || rule == "omitempty" {
+			values = append(values, rule)
+			continue
+		}
+		numbers = append(numbers, rule)
+	}
+	return strings.Join(values, ","), strings.Join(numbers, ",")
+}
+
+// validateVar 按规则校验值,校验失败时返回字段标题加规则提示
+func validateVar(fieldMeta defined.FieldMeta, field any, validate string) (err error) {
+	err = recordValidator.Var(field, validate)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		err = errors.WithMessagef(err, "field:%s,validate:%s", fieldMeta.Name, validate)
+		return err
+	}
+	msgs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		msgs = append(msgs, fieldMeta.Title+validateMessage(fieldErr))
+	}
+	return errors.New(strings.Join(msgs, ","))
+}
+
+// ReadAndValidate 按标题行读取数据并校验,校验失败时返回 CellErrors 及标注错误的文件(上传文件副本,错误单元格高亮并追加错误信息列)
+func (instance *_ExcelReader) ReadAndValidate(f *excelize.File, sheet string, fieldMetas defined.FieldMetas, options HeaderOptions) (records []map[string]string, errorFile *bytes.Buffer, err error) {
+	fieldMap, rowIndex, err := instance.MatchHeader(f, sheet, fieldMetas, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err = instance.Read(f, sheet, fieldMap, rowIndex, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = ValidateRecords(records, rowIndex, fieldMetas, fieldMap)
+	var cellErrs CellErrors
+	if !errors.As(err, &cellErrs) {
+		return records, nil, err
+	}
+	errorFile, annotateErr := instance.AnnotateErrors(f, sheet, rowIndex-1, cellErrs)
+	if annotateErr != nil {
+		return nil, nil, annotateErr
+	}
+	return records, errorFile, err
+}
+
+// AnnotateErrors 生成标注错误的文件副本(不修改 f),错误单元格高亮,在最后一列之后追加错误信息列,headerRow 为标题行行号(写入错误信息列标题,0 表示无标题行)
+func (instance *_ExcelReader) AnnotateErrors(f *excelize.File, sheet string, headerRow int, errs CellErrors) (buf *bytes.Buffer, err error) {
+	src, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	fd, err := excelize.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cols, err := fd.GetCols(sheet)
+	if err != nil {
+		return nil, err
+	}
+	errorCol, err := excelize.ColumnNumberToName(len(cols) + 1)
+	if err != nil {
+		return nil, err
+	}
+	if headerRow > 0 {
+		err = fd.SetCellStr(sheet, fmt.Sprintf("%s%d", errorCol, headerRow), ErrorColumnTitle)
+		if err != nil {
+			return nil, err
+		}
+	}
+	styleIDs := make(map[int]int) // 原样式ID=>高亮样式ID
+	rowMsgs := make(map[int][]string)
+	for _, cellErr := range errs {
+		rowMsgs[cellErr.Row] = append(rowMsgs[cellErr.Row], cellErr.Err.Error())
+		if cellErr.Col == "" {
+			continue // 未匹配到列的错误只写入错误信息列
+		}
+		cell := fmt.Sprintf("%s%d", cellErr.Col, cellErr.Row)
+		if _, _, err := excelize.CellNameToCoordinates(cell); err != nil {
+			continue
+		}
+		styleID, err := fd.GetCellStyle(sheet, cell)
+		if err != nil {
+			return nil, err
+		}
+		errorStyleID, ok := styleIDs[styleID]
+		if !ok {
+			style, err := fd.GetStyle(styleID)
+			if err != nil {
+				return nil, err
+			}
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{ErrorCellFill}}
+			errorStyleID, err = fd.NewStyle(style)
+			if err != nil {
+				return nil, err
+			}
+			styleIDs[styleID] = errorStyleID
+		}
+		err = fd.SetCellStyle(sheet, cell, cell, errorStyleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for row, msgs := range rowMsgs {
+		err = fd.SetCellStr(sheet, fmt.Sprintf("%s%d", errorCol, row), strings.Join(msgs, ";"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf, err = fd.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
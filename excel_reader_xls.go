@@ -0,0 +1,567 @@
+package excelrw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+	"github.com/richardlehane/mscfb"
+	"github.com/xuri/excelize/v2"
+)
+
+// BIFF8 记录类型
+const (
+	biffBOF         = 0x0809
+	biffEOF         = 0x000A
+	biffContinue    = 0x003C
+	biffFilePass    = 0x002F
+	biffDateMode    = 0x0022
+	biffBoundSheet  = 0x0085
+	biffSST         = 0x00FC
+	biffFormat      = 0x041E
+	biffXF          = 0x00E0
+	biffLabelSST    = 0x00FD
+	biffLabel       = 0x0204
+	biffNumber      = 0x0203
+	biffRK          = 0x027E
+	biffMulRK       = 0x00BD
+	biffBoolErr     = 0x0205
+	biffFormula     = 0x0006
+	biffString      = 0x0207
+	biffShrFmla     = 0x04BC
+	biffArray       = 0x0221
+	biffMergedCells = 0x00E5
+
+	biffVersion8 = 0x0600
+)
+
+var (
+	ErrorXlsNotSupported = errors.New("xls format not supported")
+	ErrorXlsCorrupted    = errors.New("xls file corrupted")
+)
+
+// xlsErrorValues 错误值单元格
+var xlsErrorValues = map[byte]string{
+	0x00: "#NULL!", 0x07: "#DIV/0!", 0x0F: "#VALUE!", 0x17: "#REF!", 0x1D: "#NAME?", 0x24: "#NUM!", 0x2A: "#N/A",
+}
+
+// xlsLangDateFormats 依赖区域设置的内置日期格式(通常没有 FORMAT 记录),按日期格式(14)读取
+var xlsLangDateFormats = map[int]bool{
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+}
+
+// biffRecord BIFF 记录,chunks 为记录数据及后续 CONTINUE 记录数据
+type biffRecord struct {
+	typ    uint16
+	chunks [][]byte
+}
+
+func (r biffRecord) data() []byte {
+	return r.chunks[0]
+}
+
+// biffReader 跨 CONTINUE 记录读取数据
+type biffReader struct {
+	chunks [][]byte
+	i      int
+	pos    int
+}
+
+func (r *biffReader) next() bool {
+	for r.i < len(r.chunks) && r.pos >= len(r.chunks[r.i]) {
+		r.i++
+		r.pos = 0
+	}
+	return r.i < len(r.chunks)
+}
+
+func (r *biffReader) bytes(n int) (b []byte, err error) {
+	b = make([]byte, 0, n)
+	for len(b) < n {
+		if !r.next() {
+			return nil, errors.WithMessage(ErrorXlsCorrupted, "unexpected end of record")
+		}
+		chunk := r.chunks[r.i][r.pos:]
+		size := min(n-len(b), len(chunk))
+		b = append(b, chunk[:size]...)
+		r.pos += size
+	}
+	return b, nil
+}
+
+func (r *biffReader) uint8() (v uint8, err error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *biffReader) uint16() (v uint16, err error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *biffReader) uint32() (v uint32, err error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// unicodeString 读取 XLUnicodeString,cchSize 为字符数长度字节数(1或2);字符跨 CONTINUE 记录时,新记录以压缩标志开头
+func (r *biffReader) unicodeString(cchSize int) (s string, err error) {
+	var cch int
+	if cchSize == 1 {
+		n, err := r.uint8()
+		if err != nil {
+			return "", err
+		}
+		cch = int(n)
+	} else {
+		n, err := r.uint16()
+		if err != nil {
+			return "", err
+		}
+		cch = int(n)
+	}
+	flags, err := r.uint8()
+	if err != nil {
+		return "", err
+	}
+	var runs, extSize int
+	if flags&0x08 != 0 { // 富文本
+		n, err := r.uint16()
+		if err != nil {
+			return "", err
+		}
+		runs = int(n)
+	}
+	if flags&0x04 != 0 { // 东亚语音信息
+		n, err := r.uint32()
+		if err != nil {
+			return "", err
+		}
+		extSize = int(n)
+	}
+	highByte := flags&0x01 != 0
+	units := make([]uint16, 0, cch)
+	for len(units) < cch {
+		if !r.next() {
+			return "", errors.WithMessage(ErrorXlsCorrupted, "unexpected end of string")
+		}
+		if r.pos == 0 && r.i > 0 { // 字符数据在 CONTINUE 记录开始(含字符串头部在上一记录末尾)时,以压缩标志开头
+			flag, err := r.uint8()
+			if err != nil {
+				return "", err
+			}
+			highByte = flag&0x01 != 0
+			continue
+		}
+		chunk := r.chunks[r.i][r.pos:]
+		if highByte {
+			if len(chunk) < 2 { // 记录截断或格式错误,避免死循环
+				return "", errors.WithMessage(ErrorXlsCorrupted, "odd byte in utf-16 string")
+			}
+			n := min(cch-len(units), len(chunk)/2)
+			for j := 0; j < n; j++ {
+				units = append(units, binary.LittleEndian.Uint16(chunk[j*2:]))
+			}
+			r.pos += n * 2
+		} else {
+			n := min(cch-len(units), len(chunk))
+			for j := 0; j < n; j++ {
+				units = append(units, uint16(chunk[j]))
+			}
+			r.pos += n
+		}
+	}
+	if _, err = r.bytes(runs*4 + extSize); err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// readBiffRecords 读取 BIFF 记录流,CONTINUE 记录合并到前一条记录
+func readBiffRecords(stream []byte, offset int) (records []biffRecord, err error) {
+	for pos := offset; pos+4 <= len(stream); {
+		typ := binary.LittleEndian.Uint16(stream[pos:])
+		size := int(binary.LittleEndian.Uint16(stream[pos+2:]))
+		if pos+4+size > len(stream) {
+			return nil, errors.WithMessagef(ErrorXlsCorrupted, "record:0x%04X,offset:%d", typ, pos)
+		}
+		data := stream[pos+4 : pos+4+size]
+		if typ == biffContinue && len(records) > 0 {
+			last := &records[len(records)-1]
+			last.chunks = append(last.chunks, data)
+		} else {
+			records = append(records, biffRecord{typ: typ, chunks: [][]byte{data}})
+		}
+		pos += 4 + size
+		if typ == biffEOF {
+			break
+		}
+	}
+	return records, nil
+}
+
+// xlsSheet 工作表
+type xlsSheet struct {
+	name   string
+	offset int
+}
+
+// xlsCell 单元格
+type xlsCell struct {
+	row   int
+	col   int
+	xf    int
+	value any
+}
+
+// xlsWorkbook 工作簿全局信息
+type xlsWorkbook struct {
+	date1904 bool
+	sheets   []xlsSheet
+	sst      []string
+	formats  map[int]string // 格式ID=>格式代码
+	xfs      []int          // XF 序号=>格式ID
+}
+
+// OpenXls 读取 BIFF8 格式 .xls 文件到内存中的 excel 文件(表单名称、合并单元格、数字格式保持不变),与 xlsx 使用相同的读取方法
+func OpenXls(r io.Reader) (f *excelize.File, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var stream []byte
+	for {
+		entry, err := doc.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch entry.Name {
+		case "Workbook":
+			stream, err = io.ReadAll(entry)
+			if err != nil {
+				return nil, err
+			}
+		case "Book":
+			return nil, errors.WithMessage(ErrorXlsNotSupported, "BIFF5 (Excel 95) workbook")
+		}
+	}
+	if stream == nil {
+		return nil, errors.WithMessage(ErrorXlsCorrupted, "workbook stream not found")
+	}
+	wb, err := parseXlsGlobals(stream)
+	if err != nil {
+		return nil, err
+	}
+	return wb.makeFile(stream)
+}
+
+// parseXlsGlobals 解析工作簿全局记录(工作表、共享字符串、数字格式)
+func parseXlsGlobals(stream []byte) (wb *xlsWorkbook, err error) {
+	records, err := readBiffRecords(stream, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || records[0].typ != biffBOF || len(records[0].data()) < 2 || binary.LittleEndian.Uint16(records[0].data()) != biffVersion8 {
+		return nil, errors.WithMessage(ErrorXlsNotSupported, "not a BIFF8 workbook")
+	}
+	wb = &xlsWorkbook{formats: make(map[int]string)}
+	for _, record := range records {
+		reader := &biffReader{chunks: record.chunks}
+		data := record.data()
+		switch record.typ {
+		case biffFilePass:
+			return nil, errors.WithMessage(ErrorXlsNotSupported, "encrypted workbook")
+		case biffDateMode:
+			wb.date1904 = len(data) >= 2 && binary.LittleEndian.Uint16(data) == 1
+		case biffBoundSheet:
+			if len(data) < 6 {
+				return nil, errors.WithMessage(ErrorXlsCorrupted, "boundsheet")
+			}
+			reader.pos = 6
+			name, err := reader.unicodeString(1)
+			if err != nil {
+				return nil, err
+			}
+			if data[5] == 0 { // 只读取工作表(忽略图表、宏表)
+				wb.sheets = append(wb.sheets, xlsSheet{name: name, offset: int(binary.LittleEndian.Uint32(data))})
+			}
+		case biffFormat:
+			id, err := reader.uint16()
+			if err != nil {
+				return nil, err
+			}
+			code, err := reader.unicodeString(2)
+			if err != nil {
+				return nil, err
+			}
+			wb.formats[int(id)] = code
+		case biffXF:
+			if len(data) < 4 {
+				return nil, errors.WithMessage(ErrorXlsCorrupted, "xf")
+			}
+			wb.xfs = append(wb.xfs, int(binary.LittleEndian.Uint16(data[2:])))
+		case biffSST:
+			reader.pos = 4
+			count, err := reader.uint32()
+			if err != nil {
+				return nil, err
+			}
+			wb.sst = make([]string, 0, count)
+			for i := 0; i < int(count); i++ {
+				s, err := reader.unicodeString(2)
+				if err != nil {
+					return nil, err
+				}
+				wb.sst = append(wb.sst, s)
+			}
+		}
+	}
+	return wb, nil
+}
+
+// parseSheet 解析工作表单元格及合并区域
+func (wb *xlsWorkbook) parseSheet(stream []byte, sheet xlsSheet) (cells []xlsCell, merges [][4]int, err error) {
+	records, err := readBiffRecords(stream, sheet.offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, record := range records {
+		data := record.data()
+		var row, col, xf int
+		if len(data) >= 6 {
+			row, col, xf = int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), int(binary.LittleEndian.Uint16(data[4:]))
+		}
+		switch record.typ {
+		case biffLabelSST:
+			if len(data) < 10 {
+				continue
+			}
+			index := int(binary.LittleEndian.Uint32(data[6:]))
+			if index >= len(wb.sst) {
+				return nil, nil, errors.WithMessagef(ErrorXlsCorrupted, "sst index:%d", index)
+			}
+			cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: wb.sst[index]})
+		case biffLabel:
+			reader := &biffReader{chunks: record.chunks, pos: 6}
+			s, err := reader.unicodeString(2)
+			if err != nil {
+				return nil, nil, err
+			}
+			cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: s})
+		case biffNumber:
+			if len(data) < 14 {
+				continue
+			}
+			cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: math.Float64frombits(binary.LittleEndian.Uint64(data[6:]))})
+		case biffRK:
+			if len(data) < 10 {
+				continue
+			}
+			cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: decodeRK(binary.LittleEndian.Uint32(data[6:]))})
+		case biffMulRK:
+			for j, pos := 0, 4; pos+6 <= len(data)-2; j, pos = j+1, pos+6 {
+				xf := int(binary.LittleEndian.Uint16(data[pos:]))
+				cells = append(cells, xlsCell{row: row, col: col + j, xf: xf, value: decodeRK(binary.LittleEndian.Uint32(data[pos+2:]))})
+			}
+		case biffBoolErr:
+			if len(data) < 8 {
+				continue
+			}
+			var value any = data[6] != 0
+			if data[7] == 1 {
+				value = xlsErrorValues[data[6]]
+			}
+			cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: value})
+		case biffFormula:
+			if len(data) < 14 {
+				continue
+			}
+			result := data[6:14]
+			if result[6] != 0xFF || result[7] != 0xFF { // 数字结果
+				cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: math.Float64frombits(binary.LittleEndian.Uint64(result))})
+				continue
+			}
+			switch result[0] {
+			case 0: // 字符串结果在后续 STRING 记录(共享公式、数组公式的 SHRFMLA、ARRAY 记录在 STRING 之前)
+				j := i + 1
+				for j < len(records) && (records[j].typ == biffShrFmla || records[j].typ == biffArray) {
+					j++
+				}
+				if j < len(records) && records[j].typ == biffString {
+					reader := &biffReader{chunks: records[j].chunks}
+					s, err := reader.unicodeString(2)
+					if err != nil {
+						return nil, nil, err
+					}
+					cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: s})
+				}
+			case 1:
+				cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: result[2] != 0})
+			case 2:
+				cells = append(cells, xlsCell{row: row, col: col, xf: xf, value: xlsErrorValues[result[2]]})
+			}
+		case biffMergedCells:
+			if len(data) < 2 {
+				continue
+			}
+			count := int(binary.LittleEndian.Uint16(data))
+			for j, pos := 0, 2; j < count && pos+8 <= len(data); j, pos = j+1, pos+8 {
+				merges = append(merges, [4]int{
+					int(binary.LittleEndian.Uint16(data[pos:])), int(binary.LittleEndian.Uint16(data[pos+2:])),
+					int(binary.LittleEndian.Uint16(data[pos+4:])), int(binary.LittleEndian.Uint16(data[pos+6:])),
+				}) // 首行、末行、首列、末列
+			}
+		}
+	}
+	return cells, merges, nil
+}
+
+// decodeRK 解析 RK 压缩数字
+func decodeRK(rk uint32) (number float64) {
+	if rk&0x02 != 0 {
+		number = float64(int32(rk) >> 2)
+	} else {
+		number = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		number /= 100
+	}
+	return number
+}
+
+// numFmtStyle 生成单元格数字格式样式,常规格式返回 nil
+func (wb *xlsWorkbook) numFmtStyle(xf int) (style *excelize.Style) {
+	if xf < 0 || xf >= len(wb.xfs) {
+		return nil
+	}
+	numFmt := wb.xfs[xf]
+	if numFmt == 0 {
+		return nil
+	}
+	if code, ok := wb.formats[numFmt]; ok {
+		return &excelize.Style{CustomNumFmt: &code}
+	}
+	if xlsLangDateFormats[numFmt] {
+		return &excelize.Style{NumFmt: 14}
+	}
+	return &excelize.Style{NumFmt: numFmt}
+}
+
+// makeFile 将工作表写入内存中的 excel 文件
+func (wb *xlsWorkbook) makeFile(stream []byte) (f *excelize.File, err error) {
+	if len(wb.sheets) == 0 {
+		return nil, errors.WithMessage(ErrorXlsCorrupted, "no worksheet")
+	}
+	f = excelize.NewFile()
+	if wb.date1904 {
+		date1904 := true
+		err = f.SetWorkbookProps(&excelize.WorkbookPropsOptions{Date1904: &date1904})
+		if err != nil {
+			return nil, err
+		}
+	}
+	styleIDs := make(map[int]int) // XF 序号=>样式ID
+	for i, sheet := range wb.sheets {
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), sheet.name)
+		} else {
+			_, err = f.NewSheet(sheet.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		cells, merges, err := wb.parseSheet(stream, sheet)
+		if err != nil {
+			err = errors.WithMessagef(err, "sheet:%s", sheet.name)
+			return nil, err
+		}
+		err = wb.writeSheet(f, sheet.name, cells, merges, styleIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// writeSheet 按行写入单元格(流式写入要求行号递增)
+func (wb *xlsWorkbook) writeSheet(f *excelize.File, sheet string, cells []xlsCell, merges [][4]int, styleIDs map[int]int) (err error) {
+	sort.SliceStable(cells, func(i, j int) bool {
+		if cells[i].row != cells[j].row {
+			return cells[i].row < cells[j].row
+		}
+		return cells[i].col < cells[j].col
+	})
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(cells); {
+		end := start
+		for end < len(cells) && cells[end].row == cells[start].row {
+			end++
+		}
+		values := make([]any, cells[end-1].col+1)
+		for _, cell := range cells[start:end] {
+			value := excelize.Cell{Value: cell.value}
+			if _, isNumber := cell.value.(float64); isNumber {
+				styleID, ok := styleIDs[cell.xf]
+				if !ok {
+					if style := wb.numFmtStyle(cell.xf); style != nil {
+						styleID, err = f.NewStyle(style)
+						if err != nil {
+							return err
+						}
+					}
+					styleIDs[cell.xf] = styleID
+				}
+				value.StyleID = styleID
+			}
+			values[cell.col] = value
+		}
+		axis, err := excelize.CoordinatesToCellName(1, cells[start].row+1)
+		if err != nil {
+			return err
+		}
+		err = sw.SetRow(axis, values)
+		if err != nil {
+			return err
+		}
+		start = end
+	}
+	for _, merge := range merges {
+		topLeft, err := excelize.CoordinatesToCellName(merge[2]+1, merge[0]+1)
+		if err != nil {
+			return err
+		}
+		bottomRight, err := excelize.CoordinatesToCellName(merge[3]+1, merge[1]+1)
+		if err != nil {
+			return err
+		}
+		err = sw.MergeCell(topLeft, bottomRight)
+		if err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
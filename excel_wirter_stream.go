@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
@@ -66,7 +66,7 @@ func (excelWriter *_ExcelWriter) SetColWidth(streamWriter *excelize.StreamWriter
 	colLen := len(fieldMetas)
 	for i := range colLen {
 		fieldMeta := fieldMetas[i]
-		maxSize := fieldMeta.GetMaxSize()
+		maxSize := fieldMeta.ColumnWidth()
 		if maxSize > 0 {
 			col := i + 1
 			err = streamWriter.SetColWidth(col, col, float64(maxSize)) // 设置列宽
@@ -81,6 +81,14 @@ func (excelWriter *_ExcelWriter) SetColWidth(streamWriter *excelize.StreamWriter
 
 // Write2streamWriter 向写入流中写入数据
 func (excelWriter *_ExcelWriter) Write2streamWriter(streamWriter *excelize.StreamWriter, fieldMetas defined.FieldMetas, withTitleRow bool, rowNumber int, rows []map[string]string) (nextRowNumber int, err error) {
+	return excelWriter.Write2streamWriterWithCellFn(streamWriter, fieldMetas, withTitleRow, rowNumber, rows, nil)
+}
+
+// CellFn 单元格转换函数,将列值转换为 StreamWriter.SetRow 接受的单元格(如设置样式、数字类型),record 为当前行原始数据
+type CellFn func(colIndex int, rowNumber int, value string, record map[string]string) (cell any, err error)
+
+// Write2streamWriterWithCellFn 向写入流中写入数据,cellFn 不为空时使用 cellFn 转换单元格
+func (excelWriter *_ExcelWriter) Write2streamWriterWithCellFn(streamWriter *excelize.StreamWriter, fieldMetas defined.FieldMetas, withTitleRow bool, rowNumber int, rows []map[string]string, cellFn CellFn) (nextRowNumber int, err error) {
 	colLen := len(fieldMetas)
 	minColIndex := 1
 
@@ -92,7 +100,14 @@ func (excelWriter *_ExcelWriter) Write2streamWriter(streamWriter *excelize.Strea
 			dataRaw = rowNumber - 1
 		}
 		for i := range colLen {
-			row[i] = fieldMetas[i].GetValue(dataRaw, record)
+			value := fieldMetas[i].GetValue(dataRaw, record)
+			row[i] = value
+			if cellFn != nil {
+				row[i], err = cellFn(i, rowNumber, value, record)
+				if err != nil {
+					return 0, err
+				}
+			}
 		}
 
 		// 获取当前行开始写入单元地址
@@ -164,6 +179,31 @@ type ExcelStreamWriter struct {
 	//callbacks     []CallBackFnV2
 	lock        sync.Mutex
 	moveOldFile bool
+
+	sheetOptions     defined.SheetOptions
+	styles           *_StyleRegistry
+	cellStyleIDs     map[cellStyleKey]int        // 列样式ID缓存
+	titleRowNumber   int                         // 标题行行号,0 表示未写入标题行
+	titleRowCount    int                         // 标题行行数(多级标题时大于1)
+	firstDataRow     int                         // 第一个数据行行号,0 表示未写入数据
+	lastEditableRow  int                         // 最后一个数据行或可编辑空行行号
+	aggregators      map[int]*defined.Aggregator // 列汇总计算器,key 为列序号
+	titleColumnCount int                         // 写入标题行时的列数,之后追加的列标题在流写入完成后补写
+	workbook         *ExcelWorkbookWriter        // 多表单导出时共享的文件,为空时独占文件
+
+	partitionWorkbook *ExcelWorkbookWriter          // 分表写入时各表单共享的文件
+	partitions        map[string]*ExcelStreamWriter // 分表写入器,key 为小写表单名称
+	partitionNames    map[string]string             // 已分配的表单名称,key 为小写表单名称
+	partitionCount    int                           // 已分配的表单数(不含"其它"表单)
+
+	template     *_SheetTemplate // 模板表单,sheetOptions.Template 不为空时从模板文件读取
+	templateData map[string]any  // 模板占位符数据
+
+	imageLoader defined.ImageLoader // 图片加载函数(type=image 的列)
+	images      map[string]_Image   // 当前批次预加载的图片,key 为图片地址
+	imageErrors CellErrors          // 图片加载、插入失败的单元格
+
+	optionsSheet string // 下拉选项隐藏表单名称,为空表示未创建
 }
 
 type CallBackFnV2 func(fileUrl string) (err error)
@@ -182,6 +222,9 @@ func NewExcelStreamWriter(ctx context.Context, filename string) (ecw *ExcelStrea
 }
 
 func (ecw *ExcelStreamWriter) init() (err error) {
+	if ecw.isPartitioned() {
+		return ecw.initPartition()
+	}
 	if ecw.fd != nil { // 后续优化，这里要加锁，防止并发初始化
 		return nil
 	}
@@ -193,19 +236,120 @@ func (ecw *ExcelStreamWriter) init() (err error) {
 		return nil
 	}
 
-	fd, err := ecw.excelWriter.GetFile(ecw.filename, ecw.sheet, ecw.moveOldFile)
+	fd, err := ecw.getFile()
+	if err != nil {
+		return err
+	}
+	err = ecw.setSheetProps(fd)
 	if err != nil {
 		return err
 	}
 	ecw.fd = fd
-	streamWriter, nextRowNumber, err := ecw.excelWriter.GetStreamWriter(fd, ecw.sheet)
+	ecw.styles = newStyleRegistry(fd)
+	ecw.cellStyleIDs = make(map[cellStyleKey]int)
+	ecw.aggregators = make(map[int]*defined.Aggregator)
+	if ecw.template != nil {
+		ecw.streamWriter, err = fd.NewStreamWriter(ecw.sheet) // 模板内容由 writeTemplateHead 写入(保留样式、公式)
+		if err != nil {
+			return err
+		}
+	} else {
+		streamWriter, nextRowNumber, err := ecw.excelWriter.GetStreamWriter(fd, ecw.sheet)
+		if err != nil {
+			return err
+		}
+		ecw.nextRowNumber = nextRowNumber
+		ecw.streamWriter = streamWriter
+	}
+	err = ecw.setPanes()
 	if err != nil {
-		return
+		return err
+	}
+	if ecw.template != nil {
+		err = ecw.writeTemplateHead()
+		if err != nil {
+			return err
+		}
 	}
-	ecw.nextRowNumber = nextRowNumber
-	ecw.streamWriter = streamWriter
+	return nil
+}
 
-	return
+func (ecw *ExcelStreamWriter) getFile() (fd *excelize.File, err error) {
+	if ecw.workbook != nil {
+		return ecw.workbook.getFile(ecw.sheet)
+	}
+	if ecw.sheetOptions.Template != "" {
+		return ecw.getTemplateFile()
+	}
+	return ecw.excelWriter.GetFile(ecw.filename, ecw.sheet, ecw.moveOldFile)
+}
+
+// lockFile 共享文件时加锁,返回解锁函数
+func (ecw *ExcelStreamWriter) lockFile() (unlock func()) {
+	if ecw.workbook == nil {
+		return func() {}
+	}
+	ecw.workbook.lock.Lock()
+	return ecw.workbook.lock.Unlock
+}
+
+// setSheetProps 设置表属性,表属性在创建写入流时写入,必须在创建写入流之前调用
+func (ecw *ExcelStreamWriter) setSheetProps(fd *excelize.File) (err error) {
+	printOptions := ecw.sheetOptions.Print
+	if printOptions == nil || !printOptions.FitToWidth {
+		return nil
+	}
+	fitToPage := true
+	err = fd.SetSheetProps(ecw.sheet, &excelize.SheetPropsOptions{FitToPage: &fitToPage})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// setPanes 冻结标题行、前N列(必须在写入数据之前调用)
+func (ecw *ExcelStreamWriter) setPanes() (err error) {
+	xSplit := ecw.sheetOptions.FreezeColumns
+	ySplit := 0
+	if ecw.sheetOptions.FreezeHeader && ecw.withTitleRow {
+		ySplit = ecw.nextRowNumber - 1 + ecw.headerRowCount()
+	}
+	if ecw.sheetOptions.FreezeHeader && ecw.template != nil {
+		ySplit = ecw.template.markerRow - 1
+	}
+	if xSplit <= 0 && ySplit <= 0 {
+		return nil
+	}
+	activePane := "bottomRight"
+	switch {
+	case xSplit <= 0:
+		activePane = "bottomLeft"
+	case ySplit <= 0:
+		activePane = "topRight"
+	}
+	topLeftCell, err := excelize.CoordinatesToCellName(xSplit+1, ySplit+1)
+	if err != nil {
+		return err
+	}
+	err = ecw.streamWriter.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		XSplit:      xSplit,
+		YSplit:      ySplit,
+		TopLeftCell: topLeftCell,
+		ActivePane:  activePane,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// headerRowCount 标题行行数,写入标题行前按字段分组层级计算
+func (ecw *ExcelStreamWriter) headerRowCount() int {
+	if ecw.titleRowCount > 0 {
+		return ecw.titleRowCount
+	}
+	return ecw.fieldMetas.HeaderDepth()
 }
 
 func (ecw *ExcelStreamWriter) GetNexRowNumber() int {
@@ -227,6 +371,26 @@ func (ecw *ExcelStreamWriter) WithFieldMetas(fieldMetas defined.FieldMetas) *Exc
 	return ecw
 }
 
+// AppendFieldMetas 追加列(如自动推断列时后续页出现的新字段),已写入标题行时,新列标题在保存时补写
+func (ecw *ExcelStreamWriter) AppendFieldMetas(fieldMetas ...defined.FieldMeta) *ExcelStreamWriter {
+	ecw.fieldMetas = append(ecw.fieldMetas, fieldMetas...)
+	for _, partition := range ecw.partitions {
+		partition.AppendFieldMetas(fieldMetas...)
+	}
+	return ecw
+}
+
+// WithTemplateData 设置模板占位符数据(sheetOptions.Template 不为空时使用)，例如：{"title":"订单报表"}
+func (ecw *ExcelStreamWriter) WithTemplateData(data map[string]any) *ExcelStreamWriter {
+	ecw.templateData = data
+	return ecw
+}
+
+func (ecw *ExcelStreamWriter) WithSheetOptions(sheetOptions defined.SheetOptions) *ExcelStreamWriter {
+	ecw.sheetOptions = sheetOptions
+	return ecw
+}
+
 func (ecw *ExcelStreamWriter) GetFilename() string {
 	return ecw.filename
 }
@@ -241,7 +405,7 @@ func (ecw *ExcelStreamWriter) AutoAdjustColumnWidth() (err error) {
 		columnNumber := i + 1
 		col, _ := excelize.ColumnNumberToName(columnNumber)
 		colMax, _ := excelize.ColumnNumberToName(columnNumber + 1)
-		maxSize := fieldMeta.GetMaxSize()                                  // 测试使用
+		maxSize := fieldMeta.ColumnWidth()                                 // 测试使用
 		err = ecw.fd.SetColWidth(ecw.sheet, col, colMax, float64(maxSize)) // 乘以256，因为excel的列宽是以1/256个字符宽度为单位的。
 		if err != nil {
 			return err
@@ -250,22 +414,21 @@ func (ecw *ExcelStreamWriter) AutoAdjustColumnWidth() (err error) {
 	return nil
 }
 
-// calFieldMetaMaxSize 计算字段最大长度，用于自动调整列宽
+// calFieldMetaMaxSize 计算字段最大显示宽度，用于自动调整列宽,样本行数 sheetOptions.WidthSampleRows(默认全部)
 func (ecw *ExcelStreamWriter) calFieldMetaMaxSize(rows []map[string]string) {
+	if sampleRows := ecw.sheetOptions.WidthSampleRows; sampleRows > 0 && len(rows) > sampleRows {
+		rows = rows[:sampleRows]
+	}
 	for i := 0; i < len(ecw.fieldMetas); i++ {
-		key := ecw.fieldMetas[i].Name
+		fieldMeta := &ecw.fieldMetas[i]
 		for _, record := range rows {
-			content := record[key]
-			lineIndex := strings.Index(content, "\n")
-			if lineIndex > 0 {
-				content = content[:lineIndex]
-			}
-			maxSize := len(content)
+			content := fieldMeta.GetValue(0, record)
+			maxSize := defined.DisplayWidth(content)
 			if isNumber(content) {
 				maxSize += 3 // 数字(如身份证)额外增加3个字符宽度，以便于显示美观
 
 			}
-			ecw.fieldMetas[i].SetMaxSize(maxSize)
+			fieldMeta.SetMaxSize(maxSize)
 		}
 	}
 }
@@ -295,8 +458,14 @@ func (ecw *ExcelStreamWriter) gethMaxLoopTimes() int {
 }
 
 func (ecw *ExcelStreamWriter) WithDeleteFile(delay time.Duration, errorHandler func(err error)) *ExcelStreamWriter {
+	deleteFileAfter(ecw.filename, delay, errorHandler)
+	return ecw
+}
+
+// deleteFileAfter 延迟删除文件
+func deleteFileAfter(filename string, delay time.Duration, errorHandler func(err error)) {
 	if delay <= 0 {
-		return ecw
+		return
 	}
 	if errorHandler == nil {
 		errorHandler = func(err error) {
@@ -307,12 +476,11 @@ func (ecw *ExcelStreamWriter) WithDeleteFile(delay time.Duration, errorHandler f
 		// 等待指定时间
 		time.Sleep(delay)
 		// 删除文件
-		err := os.Remove(ecw.filename)
+		err := os.Remove(filename)
 		if err != nil {
 			errorHandler(err)
 		}
 	}()
-	return ecw
 }
 
 // WithFetcher 设置数据获取器，用于从数据库或其他地方获取数据并写入Excel文件,可以使用SliceAny2string辅助函数 在回调FetcherFn 中转换数据类型输出
@@ -355,7 +523,12 @@ func (ecw *ExcelStreamWriter) loop() (err error) {
 	}
 	loopTimes := 0
 	maxLoopTimes := ecw.gethMaxLoopTimes()
-	defer ecw.Save()
+	defer func() {
+		saveErr := ecw.Save()
+		if err == nil {
+			err = saveErr
+		}
+	}()
 	for {
 		select {
 		case <-ecw.context.Done():
@@ -372,8 +545,13 @@ func (ecw *ExcelStreamWriter) loop() (err error) {
 		if err != nil {
 			return err
 		}
+		done := false
 		if loopTimes == 1 { // 第一次循环 ,写在len(data) == 0之前,确保需要写入标题时，一定会写入标题行数据,方便调试和测试)
-			// 使用第一次数据作为样本(包含标题和实际数据),计算最大列宽
+			data, loopTimes, done, err = ecw.fetchWidthSample(data, loopTimes, maxLoopTimes)
+			if err != nil {
+				return err
+			}
+			// 使用样本数据(包含标题和实际数据),计算最大列宽
 			ecw.calFieldMetaMaxSize(data)
 			// 设置列宽(必须在写入数据之前调用)
 			err = ecw.setColWidth()
@@ -390,6 +568,9 @@ func (ecw *ExcelStreamWriter) loop() (err error) {
 		if err != nil {
 			return err
 		}
+		if done {
+			break
+		}
 		if ecw.interval > 0 {
 			time.Sleep(ecw.interval)
 		}
@@ -398,11 +579,83 @@ func (ecw *ExcelStreamWriter) loop() (err error) {
 	return nil
 }
 
-func (ecw *ExcelStreamWriter) getTitleRow() (row map[string]string) {
-	row = ecw.fieldMetas.MakeTitleRow()
-	return row
+// fetchWidthSample 列宽样本行数大于第一页数据时,继续获取数据直到满足样本行数(样本数据一并写入),done 表示数据已获取完毕
+func (ecw *ExcelStreamWriter) fetchWidthSample(data []map[string]string, loopTimes int, maxLoopTimes int) (sample []map[string]string, nextLoopTimes int, done bool, err error) {
+	sample = data
+	for len(data) > 0 && len(sample) < ecw.sheetOptions.WidthSampleRows && loopTimes < maxLoopTimes {
+		loopTimes++
+		data, err = ecw.fetcher(loopTimes)
+		if err != nil {
+			return nil, loopTimes, false, err
+		}
+		if len(data) == 0 {
+			done = true
+			break
+		}
+		sample = append(sample, data...)
+		if ecw.interval > 0 {
+			time.Sleep(ecw.interval)
+		}
+	}
+	return sample, loopTimes, done, nil
+}
+
+// writeTitleRow 写入标题行,标题原样输出,不经过字典、格式化等处理;字段设置分组时写入多级标题并合并单元格
+func (ecw *ExcelStreamWriter) writeTitleRow() (err error) {
+	headerStyleID, err := ecw.styles.GetStyleID(ecw.sheetOptions.HeaderStyle)
+	if err != nil {
+		return err
+	}
+	depth := ecw.fieldMetas.HeaderDepth()
+	rows := make([][]any, depth)
+	for i := range rows {
+		rows[i] = make([]any, len(ecw.fieldMetas))
+		for j := range rows[i] {
+			rows[i][j] = excelize.Cell{StyleID: headerStyleID} // 合并区域内的单元格也设置样式,保证边框、背景完整
+		}
+	}
+	titleRowNumber := ecw.nextRowNumber
+	for _, headerCell := range ecw.fieldMetas.MakeHeaderCells() {
+		rows[headerCell.Row][headerCell.Col] = excelize.Cell{StyleID: headerStyleID, Value: headerCell.Title}
+		if !headerCell.IsMerged() {
+			continue
+		}
+		startCell, err := excelize.CoordinatesToCellName(headerCell.Col+1, titleRowNumber+headerCell.Row)
+		if err != nil {
+			return err
+		}
+		endCell, err := excelize.CoordinatesToCellName(headerCell.EndCol+1, titleRowNumber+headerCell.EndRow)
+		if err != nil {
+			return err
+		}
+		err = ecw.streamWriter.MergeCell(startCell, endCell)
+		if err != nil {
+			return err
+		}
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, titleRowNumber+i)
+		if err != nil {
+			return err
+		}
+		err = ecw.streamWriter.SetRow(cell, row)
+		if err != nil {
+			return err
+		}
+	}
+	ecw.titleRowNumber = titleRowNumber
+	ecw.titleRowCount = depth
+	ecw.titleColumnCount = len(ecw.fieldMetas)
+	ecw.nextRowNumber += depth
+	return nil
 }
 func (ecw *ExcelStreamWriter) setColWidth() (err error) {
+	if ecw.isPartitioned() || ecw.template != nil { // 分表写入时,各表单创建时计算列宽;模板使用模板列宽
+		return nil
+	}
+	if ecw.withTitleRow {
+		ecw.fieldMetas.FitHeaderSize() // 列宽需容纳标题(合并标题宽度分摊到所跨列)
+	}
 	err = ecw.excelWriter.SetColWidth(ecw.streamWriter, ecw.fieldMetas) // 设置列宽(必须在写入数据之前调用)
 	if err != nil {
 		return err
@@ -411,31 +664,58 @@ func (ecw *ExcelStreamWriter) setColWidth() (err error) {
 }
 
 func (ecw *ExcelStreamWriter) WriteData(rows []map[string]string) (err error) {
+	ecw.preloadImages(rows)
+	unlock := ecw.lockFile()
+	defer unlock()
 	err = ecw.init()
 	if err != nil {
 		return err
 	}
+	if ecw.isPartitioned() {
+		return ecw.writePartitions(rows)
+	}
 	fieldMetas, err := ecw.GetFiledMetas()
 	if err != nil {
 		return err
 	}
-	if ecw.withTitleRow { //增加标题行数据(因为只有一个协程在处理，所以后续改成false 即可控制输入一次)
-		titleRows := ecw.getTitleRow()
-		rows = append([]map[string]string{titleRows}, rows...) //添加到第一行
-		ecw.withTitleRow = false                               // 第一次写入标题行后，后续不再重复写入
+	if ecw.titleRowNumber > 0 && len(fieldMetas) > ecw.titleColumnCount { // 有追加列,计算追加列宽度
+		ecw.calFieldMetaMaxSize(rows)
 	}
-	ecw.nextRowNumber, err = ecw.excelWriter.Write2streamWriter(ecw.streamWriter, fieldMetas, ecw.withTitleRow, ecw.nextRowNumber, rows)
+	if ecw.withTitleRow { //写入标题行(因为只有一个协程在处理，所以后续改成false 即可控制输入一次)
+		err = ecw.writeTitleRow()
+		if err != nil {
+			return err
+		}
+		ecw.withTitleRow = false // 第一次写入标题行后，后续不再重复写入
+	}
+	if ecw.firstDataRow == 0 {
+		ecw.firstDataRow = ecw.nextRowNumber
+	}
+	ecw.nextRowNumber, err = ecw.excelWriter.Write2streamWriterWithCellFn(ecw.streamWriter, fieldMetas, false, ecw.nextRowNumber, rows, ecw.makeCell) // 标题行单独写入,__rowNumber 为 excel 行号
 	if err != nil {
 		return err
 	}
 	return err
 }
 
+// Save 刷新写入流并保存文件,共享文件时只刷新写入流,由 ExcelWorkbookWriter 保存文件
 func (ecw *ExcelStreamWriter) Save() (err error) {
+	if ecw.isPartitioned() {
+		return ecw.savePartitions()
+	}
+	unlock := ecw.lockFile()
+	defer unlock()
+	err = ecw.beforeFlush()
+	if err != nil {
+		return err
+	}
 	err = ecw.streamWriter.Flush()
 	if err != nil {
 		return err
 	}
+	if ecw.workbook != nil {
+		return nil
+	}
 	err = ecw.fd.Save()
 	if err != nil {
 		return err
@@ -444,6 +724,331 @@ func (ecw *ExcelStreamWriter) Save() (err error) {
 	if err != nil {
 		return err
 	}
+	err = ecw.afterSave()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// beforeFlush 写入流与文件共享工作表结构,筛选、打印等工作表设置在刷新前写入工作表结构,刷新时一并输出
+func (ecw *ExcelStreamWriter) beforeFlush() (err error) {
+	lastDataRow := ecw.nextRowNumber - 1
+	err = ecw.setAutoFilter() // 筛选范围不包含汇总行,需在写入汇总行之前计算
+	if err != nil {
+		return err
+	}
+	err = ecw.writeEditableRows()
+	if err != nil {
+		return err
+	}
+	err = ecw.writeFooterRow()
+	if err != nil {
+		return err
+	}
+	err = ecw.writeTemplateTail(lastDataRow)
+	if err != nil {
+		return err
+	}
+	err = ecw.setDataValidations()
+	if err != nil {
+		return err
+	}
+	err = ecw.setConditionalFormats()
+	if err != nil {
+		return err
+	}
+	err = ecw.protectSheet()
+	if err != nil {
+		return err
+	}
+	err = ecw.setPrintOptions()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// lastHeaderRowNumber 最后一个标题行行号,未写入标题行返回0
+func (ecw *ExcelStreamWriter) lastHeaderRowNumber() int {
+	if ecw.titleRowNumber == 0 {
+		return 0
+	}
+	return ecw.titleRowNumber + ecw.headerRowCount() - 1
+}
+
+func (ecw *ExcelStreamWriter) setAutoFilter() (err error) {
+	if !ecw.sheetOptions.AutoFilter || ecw.titleRowNumber == 0 || len(ecw.fieldMetas) == 0 {
+		return nil
+	}
+	lastRowNumber := max(ecw.nextRowNumber-1, ecw.lastHeaderRowNumber())
+	rangeRef, err := rangeRef(1, ecw.lastHeaderRowNumber(), len(ecw.fieldMetas), lastRowNumber)
+	if err != nil {
+		return err
+	}
+	err = ecw.fd.AutoFilter(ecw.sheet, rangeRef, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ecw *ExcelStreamWriter) setPrintOptions() (err error) {
+	printOptions := ecw.sheetOptions.Print
+	if printOptions == nil {
+		return nil
+	}
+	pageLayout := &excelize.PageLayoutOptions{}
+	if printOptions.Orientation != "" {
+		pageLayout.Orientation = &printOptions.Orientation
+	}
+	if printOptions.PaperSize > 0 {
+		pageLayout.Size = &printOptions.PaperSize
+	}
+	if printOptions.FitToWidth {
+		fitToWidth, fitToHeight := 1, 0 // 高度不限制页数
+		pageLayout.FitToWidth = &fitToWidth
+		pageLayout.FitToHeight = &fitToHeight
+	}
+	err = ecw.fd.SetPageLayout(ecw.sheet, pageLayout)
+	if err != nil {
+		return err
+	}
+	if printOptions.RepeatHeader && ecw.titleRowNumber > 0 {
+		err = ecw.fd.SetDefinedName(&excelize.DefinedName{
+			Name:     "_xlnm.Print_Titles",
+			RefersTo: fmt.Sprintf("'%s'!$%d:$%d", ecw.sheet, ecw.titleRowNumber, ecw.lastHeaderRowNumber()),
+			Scope:    ecw.sheet,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeRef 生成单元格区域，例如：A1:F100
+func rangeRef(startCol int, startRow int, endCol int, endRow int) (ref string, err error) {
+	startCell, err := excelize.CoordinatesToCellName(startCol, startRow)
+	if err != nil {
+		return "", err
+	}
+	endCell, err := excelize.CoordinatesToCellName(endCol, endRow)
+	if err != nil {
+		return "", err
+	}
+	return startCell + ":" + endCell, nil
+}
+
+type cellStyleKey struct {
+	colIndex int
+	banded   bool
+}
+
+// getAggregator 获取列汇总计算器,列未设置汇总返回 nil
+func (ecw *ExcelStreamWriter) getAggregator(colIndex int) (aggregator *defined.Aggregator, err error) {
+	fieldMeta := ecw.fieldMetas[colIndex]
+	if fieldMeta.Aggregate == "" {
+		return nil, nil
+	}
+	aggregator, ok := ecw.aggregators[colIndex]
+	if ok {
+		return aggregator, nil
+	}
+	aggregator, err = defined.NewAggregator(fieldMeta.Aggregate)
+	if err != nil {
+		err = errors.WithMessagef(err, "field:%s", fieldMeta.Name)
+		return nil, err
+	}
+	ecw.aggregators[colIndex] = aggregator
+	return aggregator, nil
+}
+
+// writeFooterRow 在数据末尾写入汇总行
+func (ecw *ExcelStreamWriter) writeFooterRow() (err error) {
+	if !ecw.fieldMetas.HasAggregate() {
+		return nil
+	}
+	footerStyleID, err := ecw.styles.GetStyleID(ecw.sheetOptions.FooterStyle)
+	if err != nil {
+		return err
+	}
+	rowNumber := ecw.nextRowNumber
+	hasData := ecw.firstDataRow > 0 && rowNumber > ecw.firstDataRow
+	row := make([]any, len(ecw.fieldMetas))
+	for i, fieldMeta := range ecw.fieldMetas {
+		aggregator, err := ecw.getAggregator(i)
+		if err != nil {
+			return err
+		}
+		if aggregator == nil {
+			row[i] = excelize.Cell{StyleID: footerStyleID}
+			continue
+		}
+		cell := excelize.Cell{Value: aggregator.Result()} // 使用公式时同时写入计算结果,未重新计算时也能显示
+		cell.StyleID, err = ecw.styles.GetStyleID(fieldMeta.Style, ecw.sheetOptions.FooterStyle)
+		if err != nil {
+			return err
+		}
+		if ecw.sheetOptions.FooterFormula && hasData {
+			ref, err := rangeRef(i+1, ecw.firstDataRow, i+1, rowNumber-1)
+			if err != nil {
+				return err
+			}
+			cell.Formula = aggregator.Formula(ref)
+		}
+		row[i] = cell
+	}
+	if ecw.fieldMetas[0].Aggregate == "" {
+		row[0] = excelize.Cell{StyleID: footerStyleID, Value: ecw.sheetOptions.GetFooterTitle()}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, rowNumber)
+	if err != nil {
+		return err
+	}
+	err = ecw.streamWriter.SetRow(cell, row)
+	if err != nil {
+		return err
+	}
+	ecw.nextRowNumber++
+	return nil
+}
+
+// makeCell 根据列类型、列样式、斑马纹生成单元格,同时累加列汇总
+func (ecw *ExcelStreamWriter) makeCell(colIndex int, rowNumber int, value string, record map[string]string) (cell any, err error) {
+	fieldMeta := ecw.fieldMetas[colIndex]
+	aggregator, err := ecw.getAggregator(colIndex)
+	if err != nil {
+		return nil, err
+	}
+	if aggregator != nil {
+		aggregator.Add(value)
+	}
+	var cellValue any = value
+	switch {
+	case fieldMeta.Type == defined.FieldType_number:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			cellValue = number
+		}
+	case defined.IsNumericAggregate(fieldMeta.Aggregate): // 汇总列按数字写入,汇总公式重新计算时才能计入
+		if number, ok := defined.ParseNumber(value); ok {
+			cellValue = number
+		}
+	}
+	switch fieldMeta.Type {
+	case defined.FieldType_richtext:
+		if runs, ok := defined.ParseRichText(value); ok {
+			cellValue = toRichTextRuns(runs)
+		}
+	case defined.FieldType_link, defined.FieldType_image:
+		cellValue, err = ecw.writeCellResource(fieldMeta, colIndex, rowNumber, value, record)
+		if err != nil {
+			return nil, err
+		}
+	}
+	styleID, err := ecw.getCellStyleID(colIndex, rowNumber)
+	if err != nil {
+		return nil, err
+	}
+	if styleID == 0 {
+		return cellValue, nil
+	}
+	return excelize.Cell{StyleID: styleID, Value: cellValue}, nil
+}
+
+// getCellStyleID 获取数据单元格样式ID(列类型样式、列样式、斑马纹合并,表单保护时未锁定列取消锁定)
+func (ecw *ExcelStreamWriter) getCellStyleID(colIndex int, rowNumber int) (styleID int, err error) {
+	fieldMeta := ecw.fieldMetas[colIndex]
+	key := cellStyleKey{
+		colIndex: colIndex,
+		banded:   ecw.sheetOptions.BandedRowFill != "" && (rowNumber-ecw.lastHeaderRowNumber())%2 == 0,
+	}
+	styleID, ok := ecw.cellStyleIDs[key]
+	if ok {
+		return styleID, nil
+	}
+	var bandedStyle *defined.CellStyle
+	if key.banded {
+		bandedStyle = &defined.CellStyle{Fill: ecw.sheetOptions.BandedRowFill}
+	}
+	var typeStyle *defined.CellStyle
+	if fieldMeta.Type == defined.FieldType_link {
+		typeStyle = linkStyle
+	}
+	var unlockedStyle *defined.CellStyle
+	unlocked := ecw.isProtected() && !fieldMeta.Locked
+	if unlocked {
+		unlockedStyle = &defined.CellStyle{Unlocked: true}
+	}
+	if ecw.template != nil && fieldMeta.Style == nil && bandedStyle == nil && typeStyle == nil { // 未设置列样式时使用模板标记行样式
+		styleID = ecw.template.StyleID(colIndex)
+		if unlocked {
+			styleID, err = ecw.styles.GetUnlockedStyleID(styleID)
+			if err != nil {
+				return 0, err
+			}
+		}
+		ecw.cellStyleIDs[key] = styleID
+		return styleID, nil
+	}
+	styleID, err = ecw.styles.GetStyleID(typeStyle, fieldMeta.Style, bandedStyle, unlockedStyle)
+	if err != nil {
+		return 0, err
+	}
+	ecw.cellStyleIDs[key] = styleID
+	return styleID, nil
+}
+
+// afterSave 流写入只能按行顺序写入,且刷新后对该表单元格的随机写入会被流内容覆盖,需要补写单元格时重新打开文件处理
+func (ecw *ExcelStreamWriter) afterSave() (err error) {
+	if ecw.titleRowNumber == 0 || len(ecw.fieldMetas) <= ecw.titleColumnCount || ecw.template != nil {
+		return nil
+	}
+	fd, err := excelize.OpenFile(ecw.filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	err = ecw.writeAppendedTitles(fd)
+	if err != nil {
+		return err
+	}
+	err = fd.Save()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeAppendedTitles 补写标题行写入后追加列的标题及列宽
+func (ecw *ExcelStreamWriter) writeAppendedTitles(fd *excelize.File) (err error) {
+	for i := ecw.titleColumnCount; i < len(ecw.fieldMetas); i++ {
+		fieldMeta := ecw.fieldMetas[i]
+		cell, err := excelize.CoordinatesToCellName(i+1, ecw.titleRowNumber)
+		if err != nil {
+			return err
+		}
+		err = fd.SetCellValue(ecw.sheet, cell, fieldMeta.Title)
+		if err != nil {
+			return err
+		}
+		if ecw.titleRowCount > 1 { // 多级标题时追加列标题纵向合并
+			endCell, err := excelize.CoordinatesToCellName(i+1, ecw.lastHeaderRowNumber())
+			if err != nil {
+				return err
+			}
+			err = fd.MergeCell(ecw.sheet, cell, endCell)
+			if err != nil {
+				return err
+			}
+		}
+		fieldMeta.SetMaxSize(defined.DisplayWidth(fieldMeta.Title))
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		err = fd.SetColWidth(ecw.sheet, col, col, float64(fieldMeta.ColumnWidth()))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,150 @@
+package excelrw
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+var conditionalFieldRegexp = regexp.MustCompile(`\{(\w+)\}`)
+
+// setConditionalFormats 将列及表单的条件格式应用到数据区域(数据行及可编辑空行,不含汇总行)
+func (ecw *ExcelStreamWriter) setConditionalFormats() (err error) {
+	startRow := ecw.firstEditableRow()
+	endRow := ecw.lastEditableRow
+	if endRow < startRow {
+		return nil
+	}
+	refs := make([]string, 0)
+	rangeOpts := make(map[string][]excelize.ConditionalFormatOptions) // 相同区域的规则合并设置
+	addOpt := func(ref string, opt excelize.ConditionalFormatOptions) {
+		if _, ok := rangeOpts[ref]; !ok {
+			refs = append(refs, ref)
+		}
+		rangeOpts[ref] = append(rangeOpts[ref], opt)
+	}
+	for i, fieldMeta := range ecw.fieldMetas {
+		for _, conditional := range fieldMeta.Conditionals {
+			ref, opt, err := ecw.makeConditionalFormat(i, i, startRow, endRow, conditional)
+			if err != nil {
+				err = errors.WithMessagef(err, "field:%s", fieldMeta.Name)
+				return err
+			}
+			addOpt(ref, opt)
+		}
+	}
+	for _, conditional := range ecw.sheetOptions.Conditionals {
+		startCol, endCol := 0, len(ecw.fieldMetas)-1
+		if conditional.Field != "" {
+			startCol = ecw.fieldIndex(conditional.Field)
+			if startCol < 0 {
+				return errors.Errorf("conditional field:%s not found", conditional.Field)
+			}
+			endCol = startCol
+		} else if conditional.Type != defined.Conditional_formula {
+			return errors.Errorf("conditional type:%s field required", conditional.Type)
+		}
+		ref, opt, err := ecw.makeConditionalFormat(startCol, endCol, startRow, endRow, conditional)
+		if err != nil {
+			return err
+		}
+		addOpt(ref, opt)
+	}
+	for _, ref := range refs {
+		err = ecw.fd.SetConditionalFormat(ecw.sheet, ref, rangeOpts[ref])
+		if err != nil {
+			err = errors.WithMessagef(err, "conditional range:%s", ref)
+			return err
+		}
+	}
+	return nil
+}
+
+// makeConditionalFormat 生成条件格式规则及应用区域,startCol、endCol 为列序号(从0开始)
+func (ecw *ExcelStreamWriter) makeConditionalFormat(startCol int, endCol int, startRow int, endRow int, conditional defined.ConditionalFormat) (ref string, opt excelize.ConditionalFormatOptions, err error) {
+	criteria, err := conditional.Criteria()
+	if err != nil {
+		return "", opt, err
+	}
+	ref, err = rangeRef(startCol+1, startRow, endCol+1, endRow)
+	if err != nil {
+		return "", opt, err
+	}
+	opt = excelize.ConditionalFormatOptions{Criteria: criteria}
+	switch conditional.Type {
+	case defined.Conditional_cell:
+		opt.Type = "cell"
+		opt.Value, opt.MinValue, opt.MaxValue = conditional.Value, conditional.MinValue, conditional.MaxValue
+	case defined.Conditional_text:
+		opt.Type = "text"
+		opt.Value = conditional.Value
+	case defined.Conditional_formula:
+		opt.Type = "formula"
+		opt.Criteria, err = ecw.resolveConditionalFormula(conditional.Formula, startRow)
+		if err != nil {
+			return "", opt, err
+		}
+	case defined.Conditional_colorScale:
+		opt.Type = "2_color_scale"
+		opt.MinType, opt.MaxType = "min", "max"
+		opt.MinColor = colorOrDefault(conditional.MinColor, defined.ConditionalMinColor_default)
+		opt.MaxColor = colorOrDefault(conditional.MaxColor, defined.ConditionalMaxColor_default)
+		if conditional.MidColor != "" {
+			opt.Type = "3_color_scale"
+			opt.MidType, opt.MidValue, opt.MidColor = "percentile", "50", conditional.MidColor
+		}
+	case defined.Conditional_dataBar:
+		opt.Type = "data_bar"
+		opt.MinType, opt.MaxType = "min", "max"
+		opt.BarColor = colorOrDefault(conditional.BarColor, defined.ConditionalBarColor_default)
+	}
+	if conditional.Style != nil {
+		format, err := ecw.fd.NewConditionalStyle(toExcelizeStyle(conditional.Style))
+		if err != nil {
+			return "", opt, err
+		}
+		opt.Format = &format
+	}
+	return ref, opt, nil
+}
+
+// resolveConditionalFormula 将公式中的 {字段名} 替换为第一个数据行该列单元格(列绝对引用,行相对引用,应用到区域时逐行计算)
+func (ecw *ExcelStreamWriter) resolveConditionalFormula(formula string, startRow int) (resolved string, err error) {
+	resolved = conditionalFieldRegexp.ReplaceAllStringFunc(formula, func(placeholder string) string {
+		index := ecw.fieldIndex(strings.Trim(placeholder, "{}"))
+		if index < 0 {
+			return placeholder // 非字段占位符(如数组常量)保持不变
+		}
+		col, e := excelize.ColumnNumberToName(index + 1)
+		if e != nil {
+			err = e
+			return placeholder
+		}
+		return "$" + col + strconv.Itoa(startRow)
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(resolved, "="), nil
+}
+
+// fieldIndex 获取字段列序号,不存在返回-1
+func (ecw *ExcelStreamWriter) fieldIndex(name string) int {
+	for i, fieldMeta := range ecw.fieldMetas {
+		if fieldMeta.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func colorOrDefault(color string, defaultColor string) string {
+	if color == "" {
+		return defaultColor
+	}
+	return color
+}
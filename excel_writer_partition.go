@@ -0,0 +1,170 @@
+package excelrw
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var sheetNameReplacer = strings.NewReplacer("[", "(", "]", ")", ":", "_", "*", "_", "?", "_", "/", "_", "\\", "_")
+
+const (
+	sheetNameMaxLength   = 31  // excel 表单名称最大长度
+	PartitionSheet_empty = "空" // 分表字段值为空时的表单名称
+)
+
+// partitionSheetName 表单名称(替换excel不支持的字符,截断超长名称)
+func partitionSheetName(value string) string {
+	name := strings.Trim(sheetNameReplacer.Replace(strings.TrimSpace(value)), "'")
+	if name == "" {
+		return PartitionSheet_empty
+	}
+	runes := []rune(name)
+	if len(runes) > sheetNameMaxLength {
+		name = string(runes[:sheetNameMaxLength])
+	}
+	return name
+}
+
+var ErrorPartitionNotSupported = errors.New("partition not supported")
+
+// isPartitioned 是否按字段值分表写入
+func (ecw *ExcelStreamWriter) isPartitioned() bool {
+	return ecw.sheetOptions.PartitionField != ""
+}
+
+// initPartition 分表写入时,各表单由共享文件的子写入器按需创建
+func (ecw *ExcelStreamWriter) initPartition() (err error) {
+	ecw.lock.Lock()
+	defer ecw.lock.Unlock()
+	if ecw.partitionWorkbook != nil {
+		return nil
+	}
+	if ecw.workbook != nil { // 多表单导出时各表单已共享文件,不支持再分表
+		err = errors.WithMessagef(ErrorPartitionNotSupported, "workbook sheet:%s,partitionField:%s", ecw.sheet, ecw.sheetOptions.PartitionField)
+		return err
+	}
+	if ecw.sheetOptions.Template != "" { // 模板只对应一个表单
+		err = errors.WithMessagef(ErrorPartitionNotSupported, "template:%s,partitionField:%s", ecw.sheetOptions.Template, ecw.sheetOptions.PartitionField)
+		return err
+	}
+	ecw.partitionWorkbook = NewExcelWorkbookWriter(ecw.context, ecw.filename)
+	if !ecw.moveOldFile {
+		ecw.partitionWorkbook.WithAppendToExistsFile()
+	}
+	ecw.partitions = make(map[string]*ExcelStreamWriter)
+	ecw.partitionNames = make(map[string]string)
+	return nil
+}
+
+// partitionValue 获取分表字段值,字段元数据中存在该字段时使用转换后的值(如字典映射)
+func (ecw *ExcelStreamWriter) partitionValue(record map[string]string) string {
+	field := ecw.sheetOptions.PartitionField
+	for _, fieldMeta := range ecw.fieldMetas {
+		if fieldMeta.Name == field {
+			return fieldMeta.GetValue(0, record)
+		}
+	}
+	return record[field]
+}
+
+// writePartitions 按分表字段值将数据写入对应表单,超过最大表单数后的新值写入"其它"表单
+func (ecw *ExcelStreamWriter) writePartitions(rows []map[string]string) (err error) {
+	sheets := make([]string, 0)
+	sheetRows := make(map[string][]map[string]string)
+	for _, record := range rows {
+		sheet := partitionSheetName(ecw.partitionValue(record))
+		key := strings.ToLower(sheet) // 表单名称不区分大小写
+		if _, ok := ecw.partitionNames[key]; !ok && !ecw.isOtherPartition(key) {
+			if ecw.partitionCount >= ecw.sheetOptions.GetPartitionMaxSheets() { // 超过最大表单数,写入"其它"表单
+				sheet = ecw.sheetOptions.GetPartitionOtherSheet()
+				key = strings.ToLower(sheet)
+			} else {
+				ecw.partitionCount++
+			}
+		}
+		if _, ok := ecw.partitionNames[key]; !ok {
+			ecw.partitionNames[key] = sheet
+		}
+		if _, ok := sheetRows[key]; !ok {
+			sheets = append(sheets, key)
+		}
+		sheetRows[key] = append(sheetRows[key], record)
+	}
+	for _, key := range sheets {
+		partition, err := ecw.getPartition(key, sheetRows[key])
+		if err != nil {
+			return err
+		}
+		err = partition.WriteData(sheetRows[key])
+		if err != nil {
+			err = errors.WithMessagef(err, "sheet:%s", partition.sheet)
+			return err
+		}
+	}
+	return nil
+}
+
+// getPartition 获取表单写入器,不存在时创建(使用首批数据计算列宽)
+func (ecw *ExcelStreamWriter) getPartition(key string, sample []map[string]string) (partition *ExcelStreamWriter, err error) {
+	partition, ok := ecw.partitions[key]
+	if ok {
+		return partition, nil
+	}
+	sheetOptions := ecw.sheetOptions
+	sheetOptions.PartitionField = ""
+	partition, err = ecw.partitionWorkbook.AddSheet(ecw.partitionNames[key])
+	if err != nil {
+		return nil, err
+	}
+	partition = partition.WithFieldMetas(slices.Clone(ecw.fieldMetas)).WithSheetOptions(sheetOptions).WithTitleRow(ecw.withTitleRow).WithImageLoader(ecw.imageLoader)
+	err = partition.init()
+	if err != nil {
+		err = errors.WithMessagef(err, "sheet:%s", partition.sheet)
+		return nil, err
+	}
+	partition.calFieldMetaMaxSize(sample)
+	err = partition.setColWidth()
+	if err != nil {
+		return nil, err
+	}
+	ecw.partitions[key] = partition
+	return partition, nil
+}
+
+func (ecw *ExcelStreamWriter) isOtherPartition(key string) bool {
+	return key == strings.ToLower(ecw.sheetOptions.GetPartitionOtherSheet())
+}
+
+// savePartitions 刷新所有表单写入流并保存文件,没有数据时写入只有标题行的默认表单
+func (ecw *ExcelStreamWriter) savePartitions() (err error) {
+	err = ecw.initPartition()
+	if err != nil {
+		return err
+	}
+	if len(ecw.partitions) == 0 {
+		key := strings.ToLower(ecw.sheet)
+		ecw.partitionNames[key] = ecw.sheet
+		partition, err := ecw.getPartition(key, nil)
+		if err != nil {
+			return err
+		}
+		err = partition.WriteData(nil)
+		if err != nil {
+			return err
+		}
+	}
+	for _, partition := range ecw.partitionWorkbook.writers {
+		err = partition.Save()
+		if err != nil {
+			err = errors.WithMessagef(err, "sheet:%s", partition.sheet)
+			return err
+		}
+	}
+	err = ecw.partitionWorkbook.Save()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package excelrw
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+// linkStyle 超链接默认样式
+var linkStyle = &defined.CellStyle{Font: &defined.FontStyle{Color: "#0563C1", Underline: true}}
+
+var ImageHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+const ImageMaxSize_default = 10 << 20 // 图片最大字节数(10MB)
+
+var ErrorImageNotAllowed = errors.New("image url not allowed")
+
+// ImageAllowFn 图片地址白名单校验函数(重定向地址同样校验)，例如：只允许公司图片 CDN 域名
+type ImageAllowFn func(u *url.URL) bool
+
+// NewHttpImageLoader 创建 http(s) 图片加载函数,只加载 allow 校验通过的地址(allow 为空时不加载任何地址),响应超过 maxSize 字节时失败(maxSize<=0 时使用 ImageMaxSize_default);
+// 不读取本地文件,图片地址来自接口数据,避免导出服务器文件、访问内网地址
+func NewHttpImageLoader(allow ImageAllowFn, maxSize int64) defined.ImageLoader {
+	if maxSize <= 0 {
+		maxSize = ImageMaxSize_default
+	}
+	checkURL := func(u *url.URL) (err error) {
+		if (u.Scheme != "http" && u.Scheme != "https") || allow == nil || !allow(u) {
+			err = errors.WithMessagef(ErrorImageNotAllowed, "url:%s", u.String())
+			return err
+		}
+		return nil
+	}
+	client := *ImageHttpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkURL(req.URL)
+	}
+	return func(src string) (data []byte, extension string, err error) {
+		u, err := url.Parse(src)
+		if err != nil {
+			return nil, "", err
+		}
+		err = checkURL(u)
+		if err != nil {
+			return nil, "", err
+		}
+		rsp, err := client.Get(src)
+		if err != nil {
+			return nil, "", err
+		}
+		defer rsp.Body.Close()
+		if rsp.StatusCode != http.StatusOK {
+			err = errors.Errorf("http status:%d", rsp.StatusCode)
+			return nil, "", err
+		}
+		data, err = io.ReadAll(io.LimitReader(rsp.Body, maxSize+1))
+		if err != nil {
+			return nil, "", err
+		}
+		if int64(len(data)) > maxSize {
+			err = errors.Errorf("image size over limit:%d", maxSize)
+			return nil, "", err
+		}
+		contentType := rsp.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		extensions, _ := mime.ExtensionsByType(contentType)
+		if len(extensions) > 0 {
+			extension = extensions[0]
+		} else {
+			extension = filepath.Ext(u.Path)
+		}
+		return data, extension, nil
+	}
+}
+
+// WithImageLoader 设置图片加载函数(type=image 的列),未设置时不加载图片,单元格显示图片地址;例如：NewHttpImageLoader(allow, 0)
+func (ecw *ExcelStreamWriter) WithImageLoader(loader defined.ImageLoader) *ExcelStreamWriter {
+	ecw.imageLoader = loader
+	return ecw
+}
+
+// GetImageErrors 获取图片加载、插入失败的单元格(单元格保留图片地址),保存后调用
+func (ecw *ExcelStreamWriter) GetImageErrors() CellErrors {
+	if ecw.isPartitioned() && ecw.partitionWorkbook != nil {
+		errs := make(CellErrors, 0)
+		for _, partition := range ecw.partitionWorkbook.writers {
+			errs = append(errs, partition.imageErrors...)
+		}
+		return errs
+	}
+	return ecw.imageErrors
+}
+
+// _Image 预加载的图片
+type _Image struct {
+	data      []byte
+	extension string
+	err       error
+}
+
+// preloadImages 加载一批数据中的图片(在文件锁之外执行,并行导出多个表单时下载不阻塞其它表单写入)
+func (ecw *ExcelStreamWriter) preloadImages(rows []map[string]string) {
+	ecw.images = nil
+	if ecw.imageLoader == nil || ecw.isPartitioned() { // 分表时由各表单写入器加载
+		return
+	}
+	for _, fieldMeta := range ecw.fieldMetas {
+		if fieldMeta.Type != defined.FieldType_image {
+			continue
+		}
+		for _, record := range rows {
+			src := fieldMeta.GetValue(0, record)
+			if src == "" {
+				continue
+			}
+			if _, ok := ecw.images[src]; ok {
+				continue
+			}
+			if ecw.images == nil {
+				ecw.images = make(map[string]_Image)
+			}
+			ecw.images[src] = ecw.loadImage(src)
+		}
+	}
+}
+
+func (ecw *ExcelStreamWriter) loadImage(src string) (image _Image) {
+	image.data, image.extension, image.err = ecw.imageLoader(src)
+	return image
+}
+
+// writeCellResource 写入超链接、图片(流写入与工作表共享结构,刷新时一并输出),图片加载成功时单元格不显示图片地址,失败时保留并记录错误
+func (ecw *ExcelStreamWriter) writeCellResource(fieldMeta defined.FieldMeta, colIndex int, rowNumber int, value string, record map[string]string) (cellValue any, err error) {
+	cellValue = value
+	cell, err := excelize.CoordinatesToCellName(colIndex+1, rowNumber)
+	if err != nil {
+		return nil, err
+	}
+	switch fieldMeta.Type {
+	case defined.FieldType_link:
+		url := fieldMeta.GetLink(rowNumber, record)
+		if url == "" {
+			url = value
+		}
+		if url == "" {
+			return cellValue, nil
+		}
+		err = ecw.fd.SetCellHyperLink(ecw.sheet, cell, url, "External")
+		if err != nil {
+			err = errors.WithMessagef(err, "cell:%s", cell)
+			return nil, err
+		}
+	case defined.FieldType_image:
+		if value == "" || ecw.imageLoader == nil {
+			return cellValue, nil
+		}
+		image, ok := ecw.images[value]
+		if !ok { // 未预加载(如列值引用 __rowNumber)
+			image = ecw.loadImage(value)
+		}
+		if image.err == nil {
+			image.err = ecw.fd.AddPictureFromBytes(ecw.sheet, cell, &excelize.Picture{
+				Extension: image.extension,
+				File:      image.data,
+				Format:    &excelize.GraphicOptions{AutoFit: true, LockAspectRatio: true, Positioning: "oneCell", AltText: value},
+			})
+		}
+		if image.err != nil { // 加载失败、不支持的图片格式,保留图片地址并记录错误
+			col, _ := excelize.ColumnNumberToName(colIndex + 1)
+			ecw.imageErrors = append(ecw.imageErrors, CellError{Row: rowNumber, Col: col, Field: fieldMeta.Name, Value: value, Err: image.err})
+			return cellValue, nil
+		}
+		cellValue = nil
+	}
+	return cellValue, nil
+}
@@ -0,0 +1,395 @@
+package excelrw
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cbroglie/mustache"
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	TemplateMarker_rows    = "{{#rows}}" // 模板数据行标记
+	TemplateMarker_rowsEnd = "{{/rows}}"
+)
+
+var ErrorTemplateMarkerNotFound = errors.Errorf("template marker %s not found", TemplateMarker_rows)
+
+// _SheetTemplate 模板表单,标记行之前的行原样复制(填充占位符),数据从标记行开始写入(使用标记行样式),标记行之后的行在数据之后写入
+type _SheetTemplate struct {
+	markerRow  int                  // 标记行行号
+	styleIDs   []int                // 标记行各列样式
+	fieldNames []string             // 标记行各列列值模板(去除标记)，例如：{{name}}
+	numberCols map[int]bool         // 标记行设置了数字格式的列
+	headRows   []_TemplateRow       // 标记行之前的行
+	tailRows   []_TemplateRow       // 标记行之后的行
+	mergeCells []excelize.MergeCell // 合并单元格(流写入不会保留模板中的合并单元格,需要重新写入)
+	colWidths  []float64            // 列宽
+}
+
+type _TemplateRow struct {
+	rowNumber int
+	height    float64
+	cells     []excelize.Cell
+}
+
+// CopyFile 复制模板文件到导出文件
+func (excelWriter *_ExcelWriter) CopyFile(src string, dst string) (err error) {
+	srcFd, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFd.Close()
+	err = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	dstFd, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFd.Close()
+	_, err = io.Copy(dstFd, srcFd)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// getTemplateFile 复制模板并打开,表单不存在时使用模板的当前表单
+func (ecw *ExcelStreamWriter) getTemplateFile() (fd *excelize.File, err error) {
+	err = ecw.excelWriter.CopyFile(ecw.sheetOptions.Template, ecw.filename)
+	if err != nil {
+		err = errors.WithMessagef(err, "template:%s", ecw.sheetOptions.Template)
+		return nil, err
+	}
+	fd, err = excelize.OpenFile(ecw.filename)
+	if err != nil {
+		return nil, err
+	}
+	index, err := fd.GetSheetIndex(ecw.sheet)
+	if err != nil || index < 0 {
+		ecw.sheet = fd.GetSheetName(fd.GetActiveSheetIndex())
+	}
+	ecw.template, err = readSheetTemplate(fd, ecw.sheet, ecw.templateData)
+	if err != nil {
+		err = errors.WithMessagef(err, "template:%s,sheet:%s", ecw.sheetOptions.Template, ecw.sheet)
+		return nil, err
+	}
+	if len(ecw.fieldMetas) == 0 { // 未设置字段元数据时使用标记行定义的列
+		ecw.fieldMetas = ecw.template.FieldMetas()
+	}
+	ecw.withTitleRow = false // 标题由模板提供
+	return fd, nil
+}
+
+// readSheetTemplate 读取模板表单(必须在创建写入流之前调用)
+func readSheetTemplate(fd *excelize.File, sheet string, data map[string]any) (tpl *_SheetTemplate, err error) {
+	rows, err := fd.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	tpl = &_SheetTemplate{numberCols: make(map[int]bool)}
+	colCount := 0
+	for i, row := range rows {
+		colCount = max(colCount, len(row))
+		if tpl.markerRow == 0 && strings.Contains(strings.Join(row, ""), TemplateMarker_rows) {
+			tpl.markerRow = i + 1
+		}
+	}
+	if tpl.markerRow == 0 {
+		return nil, ErrorTemplateMarkerNotFound
+	}
+	tpl.mergeCells, err = fd.GetMergeCells(sheet)
+	if err != nil {
+		return nil, err
+	}
+	mergedCells, err := mergedCellNames(tpl.mergeCells)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rowNumber := i + 1
+		if rowNumber == tpl.markerRow {
+			continue
+		}
+		templateRow := _TemplateRow{rowNumber: rowNumber, cells: make([]excelize.Cell, colCount)}
+		templateRow.height, err = fd.GetRowHeight(sheet, rowNumber)
+		if err != nil {
+			return nil, err
+		}
+		for colIndex := range colCount {
+			cellName, err := excelize.CoordinatesToCellName(colIndex+1, rowNumber)
+			if err != nil {
+				return nil, err
+			}
+			cell, err := readTemplateCell(fd, sheet, cellName, data)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "cell:%s", cellName)
+			}
+			if mergedCells[cellName] { // 合并单元格只保留左上角单元格的值
+				cell.Value = nil
+				cell.Formula = ""
+			}
+			templateRow.cells[colIndex] = cell
+		}
+		if rowNumber < tpl.markerRow {
+			tpl.headRows = append(tpl.headRows, templateRow)
+		} else {
+			tpl.tailRows = append(tpl.tailRows, templateRow)
+		}
+	}
+	markerCells := rows[tpl.markerRow-1]
+	tpl.styleIDs = make([]int, colCount)
+	tpl.fieldNames = make([]string, colCount)
+	for colIndex := range colCount {
+		cellName, _ := excelize.CoordinatesToCellName(colIndex+1, tpl.markerRow)
+		tpl.styleIDs[colIndex], err = fd.GetCellStyle(sheet, cellName)
+		if err != nil {
+			return nil, err
+		}
+		style, err := fd.GetStyle(tpl.styleIDs[colIndex])
+		if err == nil && (style.NumFmt > 0 || style.CustomNumFmt != nil) {
+			tpl.numberCols[colIndex] = true
+		}
+		if colIndex < len(markerCells) {
+			name := strings.NewReplacer(TemplateMarker_rows, "", TemplateMarker_rowsEnd, "").Replace(markerCells[colIndex])
+			tpl.fieldNames[colIndex] = strings.TrimSpace(name)
+		}
+	}
+	tpl.colWidths = make([]float64, colCount)
+	for colIndex := range colCount {
+		col, _ := excelize.ColumnNumberToName(colIndex + 1)
+		tpl.colWidths[colIndex], err = fd.GetColWidth(sheet, col)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tpl, nil
+}
+
+// mergedCellNames 合并区域内除左上角外的单元格
+func mergedCellNames(mergeCells []excelize.MergeCell) (names map[string]bool, err error) {
+	names = make(map[string]bool)
+	for _, mergeCell := range mergeCells {
+		startCol, startRow, err := excelize.CellNameToCoordinates(mergeCell.GetStartAxis())
+		if err != nil {
+			return nil, err
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(mergeCell.GetEndAxis())
+		if err != nil {
+			return nil, err
+		}
+		for row := startRow; row <= endRow; row++ {
+			for col := startCol; col <= endCol; col++ {
+				if row == startRow && col == startCol {
+					continue
+				}
+				name, _ := excelize.CoordinatesToCellName(col, row)
+				names[name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// readTemplateCell 读取模板单元格(样式、公式、值),字符串中的占位符使用 data 填充
+func readTemplateCell(fd *excelize.File, sheet string, cellName string, data map[string]any) (cell excelize.Cell, err error) {
+	cell.StyleID, err = fd.GetCellStyle(sheet, cellName)
+	if err != nil {
+		return cell, err
+	}
+	cell.Formula, err = fd.GetCellFormula(sheet, cellName)
+	if err != nil {
+		return cell, err
+	}
+	value, err := fd.GetCellValue(sheet, cellName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return cell, err
+	}
+	cellType, err := fd.GetCellType(sheet, cellName)
+	if err != nil {
+		return cell, err
+	}
+	switch {
+	case value == "":
+	case cellType == excelize.CellTypeBool:
+		cell.Value = value == "1"
+	case cellType == excelize.CellTypeUnset || cellType == excelize.CellTypeNumber:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			cell.Value = number
+			break
+		}
+		cell.Value = value
+	case strings.Contains(value, "{{"):
+		cell.Value, err = mustache.Render(value, data)
+		if err != nil {
+			return cell, err
+		}
+	default:
+		cell.Value = value
+	}
+	return cell, nil
+}
+
+// FieldMetas 标记行定义的列，例如：{{#rows}}{{name}}、{{amount}}
+func (tpl *_SheetTemplate) FieldMetas() (fieldMetas defined.FieldMetas) {
+	for colIndex, name := range tpl.fieldNames {
+		if name == "" {
+			name = "{{_}}" // 空列占位(输出空值),保持列与模板对应
+		}
+		fieldMeta := defined.FieldMeta{Name: name}
+		if tpl.numberCols[colIndex] {
+			fieldMeta.Type = defined.FieldType_number
+		}
+		fieldMetas = append(fieldMetas, fieldMeta)
+	}
+	return fieldMetas
+}
+
+// StyleID 数据行列样式
+func (tpl *_SheetTemplate) StyleID(colIndex int) int {
+	if colIndex < len(tpl.styleIDs) {
+		return tpl.styleIDs[colIndex]
+	}
+	return 0
+}
+
+// writeTemplateHead 写入模板列宽及标记行之前的行(列宽必须在写入数据之前调用)
+func (ecw *ExcelStreamWriter) writeTemplateHead() (err error) {
+	tpl := ecw.template
+	for colIndex, width := range tpl.colWidths {
+		err = ecw.streamWriter.SetColWidth(colIndex+1, colIndex+1, width)
+		if err != nil {
+			return err
+		}
+	}
+	err = ecw.writeTemplateRows(tpl.headRows, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, mergeCell := range tpl.mergeCells {
+		_, endRow, err := excelize.CellNameToCoordinates(mergeCell.GetEndAxis())
+		if err != nil {
+			return err
+		}
+		if endRow < tpl.markerRow {
+			err = ecw.streamWriter.MergeCell(mergeCell.GetStartAxis(), mergeCell.GetEndAxis())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if tpl.markerRow > 1 { // 模板标记行之前的行视为标题行(用于序号、筛选、冻结等)
+		ecw.titleRowNumber = 1
+		ecw.titleRowCount = tpl.markerRow - 1
+	}
+	ecw.titleColumnCount = len(ecw.fieldMetas)
+	ecw.nextRowNumber = tpl.markerRow
+	return nil
+}
+
+// writeTemplateTail 数据之后写入模板标记行之后的行,行号偏移,公式中引用标记行的区域扩展到最后一个数据行
+func (ecw *ExcelStreamWriter) writeTemplateTail(lastDataRow int) (err error) {
+	tpl := ecw.template
+	if tpl == nil {
+		return nil
+	}
+	delta := max(ecw.nextRowNumber-(tpl.markerRow+1), 0) // 没有数据时标记行留空,公式引用空的数据区域,避免尾部行上移到引用区域内形成循环引用
+	err = ecw.writeTemplateRows(tpl.tailRows, delta, lastDataRow)
+	if err != nil {
+		return err
+	}
+	for _, mergeCell := range tpl.mergeCells {
+		startCol, startRow, err := excelize.CellNameToCoordinates(mergeCell.GetStartAxis())
+		if err != nil {
+			return err
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(mergeCell.GetEndAxis())
+		if err != nil {
+			return err
+		}
+		if startRow <= tpl.markerRow {
+			continue
+		}
+		startCell, _ := excelize.CoordinatesToCellName(startCol, startRow+delta)
+		endCell, _ := excelize.CoordinatesToCellName(endCol, endRow+delta)
+		err = ecw.streamWriter.MergeCell(startCell, endCell)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ecw *ExcelStreamWriter) writeTemplateRows(rows []_TemplateRow, delta int, lastDataRow int) (err error) {
+	for _, templateRow := range rows {
+		row := make([]any, len(templateRow.cells))
+		for i, cell := range templateRow.cells {
+			if cell.Formula != "" && delta != 0 {
+				cell.Formula = shiftFormula(cell.Formula, ecw.template.markerRow, delta, lastDataRow)
+			}
+			row[i] = cell
+		}
+		cellName, err := excelize.CoordinatesToCellName(1, templateRow.rowNumber+delta)
+		if err != nil {
+			return err
+		}
+		err = ecw.streamWriter.SetRow(cellName, row, excelize.RowOpts{Height: templateRow.height})
+		if err != nil {
+			return err
+		}
+		ecw.nextRowNumber = templateRow.rowNumber + delta + 1
+	}
+	return nil
+}
+
+var formulaCellRefExp = regexp.MustCompile(`(\$?[A-Z]{1,3}\$?)(\d+)(:\$?[A-Z]{1,3}\$?)?(\d+)?`)
+
+// shiftFormula 公式中标记行之后的单元格行号偏移 delta,区域结束行为标记行时扩展到最后一个数据行，例如：SUM(B3:B3) => SUM(B3:B12)
+func shiftFormula(formula string, markerRow int, delta int, lastDataRow int) string {
+	matches := formulaCellRefExp.FindAllStringSubmatchIndex(formula, -1)
+	var w strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if start > 0 && isFormulaNameChar(formula[start-1]) || end < len(formula) && (formula[end] == '(' || isFormulaNameChar(formula[end])) {
+			continue // 函数名、表单名等,不是单元格引用
+		}
+		w.WriteString(formula[last:start])
+		startRow, _ := strconv.Atoi(formula[match[4]:match[5]])
+		w.WriteString(formula[match[2]:match[3]])
+		w.WriteString(strconv.Itoa(shiftRow(startRow, markerRow, delta)))
+		if match[6] >= 0 && match[8] >= 0 {
+			endRow, _ := strconv.Atoi(formula[match[8]:match[9]])
+			switch {
+			case endRow == markerRow && lastDataRow >= markerRow:
+				endRow = lastDataRow
+			default:
+				endRow = shiftRow(endRow, markerRow, delta)
+			}
+			w.WriteString(formula[match[6]:match[7]])
+			w.WriteString(strconv.Itoa(endRow))
+		}
+		last = end
+	}
+	w.WriteString(formula[last:])
+	return w.String()
+}
+
+func shiftRow(row int, markerRow int, delta int) int {
+	if row > markerRow {
+		return row + delta
+	}
+	return row
+}
+
+func isFormulaNameChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
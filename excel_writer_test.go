@@ -1,13 +1,23 @@
 package excelrw_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	imagepng "image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/suifengpiao14/excelrw"
 	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestWriteWithChan(t *testing.T) {
@@ -94,3 +104,662 @@ var jsonData = `
   }
 ]
 `
+
+func TestAppendFieldMetas(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "append.xlsx")
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(defined.FieldMetas{{Name: "id", Title: "ID"}})
+	ecw.WithFetcher(func(loopIndex int) (rows []map[string]string, err error) {
+		switch loopIndex {
+		case 1:
+			return []map[string]string{{"id": "1"}}, nil
+		case 2:
+			ecw.AppendFieldMetas(defined.FieldMeta{Name: "remark", Title: "备注"})
+			return []map[string]string{{"id": "2", "remark": "new"}}, nil
+		}
+		return nil, nil
+	})
+	errChan, err := ecw.Run()
+	require.NoError(t, err)
+	require.NoError(t, <-errChan)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	rows, err := fd.GetRows(excelrw.SheetDefault)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"ID", "备注"}, {"1"}, {"2", "new"}}, rows)
+}
+
+func TestWriteWithStyle(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "style.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "name", Title: "名称"},
+		{Name: "amount", Title: "金额", Type: defined.FieldType_number, Style: &defined.CellStyle{Horizontal: "right", NumFmt: "#,##0.00"}},
+	}
+	sheetOptions := defined.SheetOptions{
+		HeaderStyle:   &defined.CellStyle{Font: &defined.FontStyle{Bold: true}, Fill: "#D9E1F2", Border: "#BFBFBF"},
+		BandedRowFill: "#F2F2F2",
+	}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	err := ecw.WriteData([]map[string]string{{"name": "a", "amount": "1234.5"}, {"name": "b", "amount": "x"}})
+	require.NoError(t, err)
+	require.NoError(t, ecw.Save())
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	headerStyleID, err := fd.GetCellStyle(sheet, "A1")
+	require.NoError(t, err)
+	headerStyle, err := fd.GetStyle(headerStyleID)
+	require.NoError(t, err)
+	require.True(t, headerStyle.Font.Bold)
+
+	cellType, err := fd.GetCellType(sheet, "B2")
+	require.NoError(t, err)
+	require.NotEqual(t, excelize.CellTypeInlineString, cellType)
+	amount, err := fd.GetCellValue(sheet, "B2")
+	require.NoError(t, err)
+	require.Equal(t, "1,234.50", amount)
+
+	oddStyleID, err := fd.GetCellStyle(sheet, "A2")
+	require.NoError(t, err)
+	evenStyleID, err := fd.GetCellStyle(sheet, "A3")
+	require.NoError(t, err)
+	require.Equal(t, 0, oddStyleID)
+	evenStyle, err := fd.GetStyle(evenStyleID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"F2F2F2"}, evenStyle.Fill.Color)
+}
+
+func TestWriteWithSheetSetup(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "setup.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "id", Title: "ID"},
+		{Name: "name", Title: "名称"},
+	}
+	sheetOptions := defined.SheetOptions{
+		FreezeHeader:  true,
+		FreezeColumns: 1,
+		AutoFilter:    true,
+		Print: &defined.PrintOptions{
+			Orientation:  defined.Orientation_landscape,
+			FitToWidth:   true,
+			RepeatHeader: true,
+		},
+	}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	err := ecw.WriteData([]map[string]string{{"id": "1", "name": "a"}, {"id": "2", "name": "b"}})
+	require.NoError(t, err)
+	require.NoError(t, ecw.Save())
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	panes, err := fd.GetPanes(sheet)
+	require.NoError(t, err)
+	require.True(t, panes.Freeze)
+	require.Equal(t, 1, panes.XSplit)
+	require.Equal(t, 1, panes.YSplit)
+	require.Equal(t, "B2", panes.TopLeftCell)
+
+	pageLayout, err := fd.GetPageLayout(sheet)
+	require.NoError(t, err)
+	require.Equal(t, defined.Orientation_landscape, *pageLayout.Orientation)
+	require.Equal(t, 1, *pageLayout.FitToWidth)
+
+	definedNames := fd.GetDefinedName()
+	require.Len(t, definedNames, 2)
+	refersTo := map[string]string{}
+	for _, definedName := range definedNames {
+		refersTo[definedName.Name] = definedName.RefersTo
+	}
+	require.Equal(t, "'sheet1'!$A$1:$B$3", refersTo["_xlnm._FilterDatabase"])
+	require.Equal(t, "'sheet1'!$1:$1", refersTo["_xlnm.Print_Titles"])
+}
+
+func TestWriteWithGroupHeader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "group.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "__rowNumber", Title: "序号"},
+		{Name: "name", Title: "姓名", Group: []string{"买家信息", "姓名"}},
+		{Name: "phone", Title: "电话", Group: []string{"买家信息", "电话"}},
+		{Name: "amount", Title: "金额"},
+	}
+	sheetOptions := defined.SheetOptions{FreezeHeader: true, AutoFilter: true}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	ecw.WithFetcher(func(loopIndex int) (rows []map[string]string, err error) {
+		if loopIndex == 1 {
+			return []map[string]string{{"name": "a", "phone": "1", "amount": "10"}, {"name": "b", "phone": "2", "amount": "20"}}, nil
+		}
+		return nil, nil
+	})
+	errChan, err := ecw.Run()
+	require.NoError(t, err)
+	require.NoError(t, <-errChan)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	rows, err := fd.GetRows(sheet)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"序号", "买家信息", "", "金额"},
+		{"", "姓名", "电话"},
+		{"3", "a", "1", "10"}, // __rowNumber 为 excel 行号
+		{"4", "b", "2", "20"},
+	}, rows)
+
+	mergeCells, err := fd.GetMergeCells(sheet)
+	require.NoError(t, err)
+	refs := make([]string, 0, len(mergeCells))
+	for _, mergeCell := range mergeCells {
+		refs = append(refs, mergeCell.GetStartAxis()+":"+mergeCell.GetEndAxis())
+	}
+	require.ElementsMatch(t, []string{"A1:A2", "B1:C1", "D1:D2"}, refs)
+
+	panes, err := fd.GetPanes(sheet)
+	require.NoError(t, err)
+	require.Equal(t, 2, panes.YSplit)
+
+	widthB, err := fd.GetColWidth(sheet, "B")
+	require.NoError(t, err)
+	widthC, err := fd.GetColWidth(sheet, "C")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, widthB+widthC, float64(defined.DisplayWidth("买家信息")))
+}
+
+func TestWriteWithFooter(t *testing.T) {
+	fieldMetas := defined.FieldMetas{
+		{Name: "name", Title: "名称"},
+		{Name: "amount", Title: "金额", Type: defined.FieldType_number, Aggregate: defined.Aggregate_sum},
+		{Name: "price", Title: "单价", Aggregate: defined.Aggregate_avg},
+		{Name: "city", Title: "城市", Aggregate: defined.Aggregate_distinct},
+	}
+	rows := []map[string]string{
+		{"name": "a", "amount": "10", "price": "1", "city": "sz"},
+		{"name": "b", "amount": "20.5", "price": "2", "city": "gz"},
+		{"name": "c", "amount": "x", "price": "3", "city": "sz"},
+	}
+	t.Run("value", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "footer.xlsx")
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas)
+		require.NoError(t, ecw.WriteData(rows))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		values, err := fd.GetRows(excelrw.SheetDefault)
+		require.NoError(t, err)
+		require.Equal(t, []string{"合计", "30.5", "2", "2"}, values[4])
+	})
+	t.Run("formula", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "footer.xlsx")
+		sheetOptions := defined.SheetOptions{FooterTitle: "总计", FooterFormula: true, FooterStyle: &defined.CellStyle{Font: &defined.FontStyle{Bold: true}}}
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+		require.NoError(t, ecw.WriteData(rows))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		sheet := excelrw.SheetDefault
+		title, err := fd.GetCellValue(sheet, "A5")
+		require.NoError(t, err)
+		require.Equal(t, "总计", title)
+		formula, err := fd.GetCellFormula(sheet, "B5")
+		require.NoError(t, err)
+		require.Equal(t, "SUM(B2:B4)", formula)
+		formula, err = fd.GetCellFormula(sheet, "D5")
+		require.NoError(t, err)
+		require.Equal(t, `SUMPRODUCT((D2:D4<>"")/COUNTIF(D2:D4,D2:D4&""))`, formula)
+		for _, cell := range []string{"C2", "C3", "C4"} { // 汇总列非 number 类型时也按数字写入(文本单元格不参与 SUM/AVERAGE 等公式计算)
+			cellType, err := fd.GetCellType(sheet, cell)
+			require.NoError(t, err)
+			require.NotContains(t, []excelize.CellType{excelize.CellTypeInlineString, excelize.CellTypeSharedString}, cellType, cell)
+		}
+		for cell, expect := range map[string]string{"B5": "30.5", "C5": "2"} { // 重新计算公式结果
+			value, err := fd.CalcCellValue(sheet, cell)
+			require.NoError(t, err)
+			require.Equal(t, expect, value, cell)
+		}
+		styleID, err := fd.GetCellStyle(sheet, "B5")
+		require.NoError(t, err)
+		style, err := fd.GetStyle(styleID)
+		require.NoError(t, err)
+		require.True(t, style.Font.Bold)
+	})
+}
+
+func TestWorkbookWriter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "workbook.xlsx")
+	workbook := excelrw.NewExcelWorkbookWriter(context.Background(), filename).WithParallel(true)
+	pages := map[string][]map[string]string{
+		"订单": {{"id": "1"}, {"id": "2"}},
+		"退款": {{"id": "3"}},
+	}
+	for _, sheet := range []string{"订单", "退款", "失败"} {
+		ecw, err := workbook.AddSheet(sheet)
+		require.NoError(t, err)
+		ecw.WithFieldMetas(defined.FieldMetas{{Name: "id", Title: "ID"}}).WithFetcher(func(loopIndex int) (rows []map[string]string, err error) {
+			if sheet == "失败" {
+				return nil, errors.New("fetch failed")
+			}
+			if loopIndex == 1 {
+				return pages[sheet], nil
+			}
+			return nil, nil
+		})
+	}
+	_, err := workbook.AddSheet("订单")
+	require.Error(t, err)
+
+	errChan, err := workbook.Run()
+	require.NoError(t, err)
+	err = <-errChan
+	var sheetErrs excelrw.SheetErrors
+	require.ErrorAs(t, err, &sheetErrs)
+	require.Len(t, sheetErrs, 1)
+	require.Equal(t, "失败", sheetErrs[0].Sheet)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	require.Equal(t, []string{"订单", "退款", "失败"}, fd.GetSheetList())
+	rows, err := fd.GetRows("订单")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"ID"}, {"1"}, {"2"}}, rows)
+	rows, err = fd.GetRows("退款")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"ID"}, {"3"}}, rows)
+}
+
+func TestWriteWithPartition(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "partition.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "__rowNumber", Title: "序号"},
+		{Name: "city", Title: "城市", Dict: map[string]string{"sz": "深圳", "gz": "广州", "bj": "北京"}},
+		{Name: "amount", Title: "金额"},
+	}
+	sheetOptions := defined.SheetOptions{PartitionField: "city", PartitionMaxSheets: 2}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	ecw.WithFetcher(func(loopIndex int) (rows []map[string]string, err error) {
+		switch loopIndex {
+		case 1:
+			return []map[string]string{{"city": "sz", "amount": "1"}, {"city": "gz", "amount": "2"}, {"city": "sz", "amount": "3"}}, nil
+		case 2:
+			return []map[string]string{{"city": "bj", "amount": "4"}, {"city": "gz", "amount": "5"}}, nil
+		}
+		return nil, nil
+	})
+	errChan, err := ecw.Run()
+	require.NoError(t, err)
+	require.NoError(t, <-errChan)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	require.Equal(t, []string{"深圳", "广州", defined.PartitionOtherSheet_default}, fd.GetSheetList())
+	rows, err := fd.GetRows("深圳")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"序号", "城市", "金额"}, {"2", "深圳", "1"}, {"3", "深圳", "3"}}, rows)
+	rows, err = fd.GetRows("广州")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"序号", "城市", "金额"}, {"2", "广州", "2"}, {"3", "广州", "5"}}, rows)
+	rows, err = fd.GetRows(defined.PartitionOtherSheet_default)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"序号", "城市", "金额"}, {"2", "北京", "4"}}, rows)
+
+	t.Run("append", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "append.xlsx")
+		existing := excelize.NewFile()
+		require.NoError(t, existing.SetSheetName("Sheet1", "已有"))
+		require.NoError(t, existing.SetCellValue("已有", "A1", "keep"))
+		require.NoError(t, existing.SaveAs(filename))
+		require.NoError(t, existing.Close())
+
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions).WithAppendToExistsFile()
+		require.NoError(t, ecw.WriteData([]map[string]string{{"city": "sz", "amount": "1"}}))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		require.Equal(t, []string{"已有", "深圳"}, fd.GetSheetList())
+		value, err := fd.GetCellValue("已有", "A1")
+		require.NoError(t, err)
+		require.Equal(t, "keep", value)
+	})
+	t.Run("workbook", func(t *testing.T) {
+		workbook := excelrw.NewExcelWorkbookWriter(context.Background(), filepath.Join(t.TempDir(), "workbook.xlsx"))
+		ecw, err := workbook.AddSheet("订单")
+		require.NoError(t, err)
+		ecw.WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions).WithFetcher(func(loopIndex int) (rows []map[string]string, err error) { return nil, nil })
+		_, err = workbook.Run()
+		require.ErrorIs(t, err, excelrw.ErrorPartitionNotSupported)
+	})
+}
+
+func TestWriteWithTemplate(t *testing.T) {
+	dir := t.TempDir()
+	templateFile := filepath.Join(dir, "template.xlsx")
+	tpl := excelize.NewFile()
+	sheet := "报表"
+	require.NoError(t, tpl.SetSheetName("Sheet1", sheet))
+	boldStyleID, err := tpl.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	require.NoError(t, err)
+	amountStyleID, err := tpl.NewStyle(&excelize.Style{NumFmt: 4})
+	require.NoError(t, err)
+	require.NoError(t, tpl.SetCellValue(sheet, "A1", "{{title}}({{creator}})"))
+	require.NoError(t, tpl.SetCellStyle(sheet, "A1", "A1", boldStyleID))
+	require.NoError(t, tpl.MergeCell(sheet, "A1", "C1"))
+	require.NoError(t, tpl.SetSheetRow(sheet, "A2", &[]any{"名称", "金额", "备注"}))
+	require.NoError(t, tpl.SetSheetRow(sheet, "A3", &[]any{"{{#rows}}{{name}}", "{{amount}}", "{{remark}}{{/rows}}"}))
+	require.NoError(t, tpl.SetCellStyle(sheet, "B3", "B3", amountStyleID))
+	require.NoError(t, tpl.SetCellValue(sheet, "A4", "合计"))
+	require.NoError(t, tpl.SetCellFormula(sheet, "B4", "SUM(B3:B3)"))
+	require.NoError(t, tpl.MergeCell(sheet, "A5", "C5"))
+	require.NoError(t, tpl.SetCellValue(sheet, "A5", "备注:{{title}}"))
+	require.NoError(t, tpl.SetColWidth(sheet, "C", "C", 30))
+	require.NoError(t, tpl.SaveAs(templateFile))
+	require.NoError(t, tpl.Close())
+
+	filename := filepath.Join(dir, "export.xlsx")
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithSheetOptions(defined.SheetOptions{Template: templateFile})
+	ecw.WithTemplateData(map[string]any{"title": "订单报表", "creator": "张三"})
+	err = ecw.WriteData([]map[string]string{{"name": "a", "amount": "1000", "remark": "x"}, {"name": "b", "amount": "20"}})
+	require.NoError(t, err)
+	require.NoError(t, ecw.Save())
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	rows, err := fd.GetRows(sheet)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"订单报表(张三)"},
+		{"名称", "金额", "备注"},
+		{"a", "1,000.00", "x"},
+		{"b", "20.00"},
+		{"合计", ""},
+		{"备注:订单报表"},
+	}, rows)
+	formula, err := fd.GetCellFormula(sheet, "B5")
+	require.NoError(t, err)
+	require.Equal(t, "SUM(B3:B4)", formula)
+	styleID, err := fd.GetCellStyle(sheet, "A1")
+	require.NoError(t, err)
+	require.Equal(t, boldStyleID, styleID)
+	mergeCells, err := fd.GetMergeCells(sheet)
+	require.NoError(t, err)
+	refs := make([]string, 0, len(mergeCells))
+	for _, mergeCell := range mergeCells {
+		refs = append(refs, mergeCell.GetStartAxis()+":"+mergeCell.GetEndAxis())
+	}
+	require.ElementsMatch(t, []string{"A1:C1", "A6:C6"}, refs)
+	width, err := fd.GetColWidth(sheet, "C")
+	require.NoError(t, err)
+	require.Equal(t, float64(30), width)
+
+	t.Run("empty", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "empty.xlsx")
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithSheetOptions(defined.SheetOptions{Template: templateFile})
+		require.NoError(t, ecw.WriteData(nil))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		formula, err := fd.GetCellFormula(sheet, "B4") // 标记行留空,汇总行位置不变,不引用自身
+		require.NoError(t, err)
+		require.Equal(t, "SUM(B3:B3)", formula)
+		value, err := fd.CalcCellValue(sheet, "B4")
+		require.NoError(t, err)
+		require.Equal(t, "0", value)
+	})
+	t.Run("partition", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "partition.xlsx")
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithSheetOptions(defined.SheetOptions{Template: templateFile, PartitionField: "name"})
+		err := ecw.WriteData([]map[string]string{{"name": "a"}})
+		require.ErrorIs(t, err, excelrw.ErrorPartitionNotSupported)
+	})
+}
+
+func TestWriteWithCellTypes(t *testing.T) {
+	var png bytes.Buffer
+	require.NoError(t, imagepng.Encode(&png, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/thumb.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png.Bytes())
+	}))
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "types.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "orderNo", Title: "订单号", Type: defined.FieldType_link, Link: "https://example.com/order/{{id}}"},
+		{Name: "thumb", Title: "图片", Type: defined.FieldType_image},
+		{Name: "status", Title: "状态", Type: defined.FieldType_richtext},
+	}
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	allow := func(u *url.URL) bool { return u.Host == serverURL.Host }
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithImageLoader(excelrw.NewHttpImageLoader(allow, 0))
+	err = ecw.WriteData([]map[string]string{
+		{"id": "1", "orderNo": "NO001", "thumb": server.URL + "/thumb.png", "status": `[{"text":"逾期","font":{"bold":true,"color":"#FF0000"}},{"text":"3天"}]`},
+		{"id": "2", "orderNo": "NO002", "thumb": server.URL + "/missing.png", "status": "正常"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ecw.Save())
+	imageErrs := ecw.GetImageErrors()
+	require.Len(t, imageErrs, 1)
+	require.Equal(t, "B", imageErrs[0].Col)
+	require.Equal(t, 3, imageErrs[0].Row)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	ok, link, err := fd.GetCellHyperLink(sheet, "A2")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/order/1", link)
+	value, err := fd.GetCellValue(sheet, "A2")
+	require.NoError(t, err)
+	require.Equal(t, "NO001", value)
+
+	pictures, err := fd.GetPictures(sheet, "B2")
+	require.NoError(t, err)
+	require.Len(t, pictures, 1)
+	require.Equal(t, png.Bytes(), pictures[0].File)
+	value, err = fd.GetCellValue(sheet, "B3")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/missing.png", value) // 加载失败保留图片地址
+
+	runs, err := fd.GetCellRichText(sheet, "C2")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "逾期", runs[0].Text)
+	require.True(t, runs[0].Font.Bold)
+	value, err = fd.GetCellValue(sheet, "C3")
+	require.NoError(t, err)
+	require.Equal(t, "正常", value)
+
+	t.Run("imageLoader", func(t *testing.T) {
+		loader := excelrw.NewHttpImageLoader(allow, 0)
+		_, _, err := loader("/etc/passwd") // 不读取本地文件
+		require.ErrorIs(t, err, excelrw.ErrorImageNotAllowed)
+		_, _, err = excelrw.NewHttpImageLoader(func(u *url.URL) bool { return false }, 0)(server.URL + "/thumb.png")
+		require.ErrorIs(t, err, excelrw.ErrorImageNotAllowed)
+		_, _, err = excelrw.NewHttpImageLoader(nil, 0)(server.URL + "/thumb.png")
+		require.ErrorIs(t, err, excelrw.ErrorImageNotAllowed)
+		_, _, err = excelrw.NewHttpImageLoader(allow, 8)(server.URL + "/thumb.png")
+		require.ErrorContains(t, err, "image size over limit:8")
+	})
+	t.Run("withoutLoader", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "url.xlsx")
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas)
+		require.NoError(t, ecw.WriteData([]map[string]string{{"thumb": server.URL + "/thumb.png"}}))
+		require.NoError(t, ecw.Save())
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		value, err := fd.GetCellValue(excelrw.SheetDefault, "B2") // 未设置图片加载函数时不下载,显示图片地址
+		require.NoError(t, err)
+		require.Equal(t, server.URL+"/thumb.png", value)
+	})
+}
+
+func TestWriteWithValidation(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "validation.xlsx")
+	longOptions := make([]string, 0)
+	for i := range 100 {
+		longOptions = append(longOptions, fmt.Sprintf("选项%d", i))
+	}
+	fieldMetas := defined.FieldMetas{
+		{Name: "id", Title: "ID", Locked: true},
+		{Name: "status", Title: "状态", Options: []string{"待使用", "已使用"}},
+		{Name: "type", Title: "类型", Options: longOptions},
+	}
+	sheetOptions := defined.SheetOptions{ProtectPassword: "123456", EditableRows: 10}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	require.NoError(t, ecw.WriteData([]map[string]string{{"id": "1", "status": "待使用", "type": "选项1"}}))
+	require.NoError(t, ecw.Save())
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	validations, err := fd.GetDataValidations(sheet)
+	require.NoError(t, err)
+	require.Len(t, validations, 2)
+	require.Equal(t, "B2:B1048576", validations[0].Sqref)
+	require.Equal(t, `"待使用,已使用"`, validations[0].Formula1)
+	require.Equal(t, "'_options'!$A$1:$A$100", validations[1].Formula1)
+	visible, err := fd.GetSheetVisible(excelrw.OptionsSheet)
+	require.NoError(t, err)
+	require.False(t, visible)
+
+	locked := func(cell string) bool {
+		styleID, err := fd.GetCellStyle(sheet, cell)
+		require.NoError(t, err)
+		style, err := fd.GetStyle(styleID)
+		require.NoError(t, err)
+		return style.Protection == nil || style.Protection.Locked
+	}
+	require.True(t, locked("A2"))
+	require.False(t, locked("B2"))
+	require.False(t, locked("B11")) // 可编辑空行
+	require.True(t, locked("B1"))   // 标题行
+	require.ErrorIs(t, fd.UnprotectSheet(sheet, "wrong"), excelize.ErrUnprotectSheetPassword)
+	require.NoError(t, fd.UnprotectSheet(sheet, "123456"))
+
+	t.Run("footerAndPartition", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "footer.xlsx")
+		fieldMetas := defined.FieldMetas{
+			{Name: "id", Title: "ID", Locked: true},
+			{Name: "group", Title: "分组"},
+			{Name: "amount", Title: "金额", Aggregate: defined.Aggregate_sum},
+			{Name: "type", Title: "类型", Options: longOptions},
+		}
+		sheetOptions := defined.SheetOptions{FooterFormula: true, PartitionField: "group"}
+		ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+		require.NoError(t, ecw.WriteData([]map[string]string{{"id": "1", "group": excelrw.OptionsSheet, "amount": "2"}, {"id": "2", "group": excelrw.OptionsSheet, "amount": "3"}}))
+		require.NoError(t, ecw.Save())
+
+		fd, err := excelize.OpenFile(filename)
+		require.NoError(t, err)
+		defer fd.Close()
+		formula, err := fd.GetCellFormula(excelrw.OptionsSheet, "C4") // 汇总行紧跟数据,不写入可编辑空行
+		require.NoError(t, err)
+		require.Equal(t, "SUM(C2:C3)", formula)
+		validations, err := fd.GetDataValidations(excelrw.OptionsSheet)
+		require.NoError(t, err)
+		require.Len(t, validations, 1)
+		require.Equal(t, "'_options1'!$A$1:$A$100", validations[0].Formula1) // 分表名称与选项表单名称相同时追加序号
+		visible, err := fd.GetSheetVisible(excelrw.OptionsSheet)
+		require.NoError(t, err)
+		require.True(t, visible)
+	})
+}
+
+func TestWriteWithConditionalFormat(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "conditional.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "name", Title: "名称", Conditionals: []defined.ConditionalFormat{{Type: defined.Conditional_text, Value: "vip", Style: &defined.CellStyle{Font: &defined.FontStyle{Bold: true}}}}},
+		{Name: "amount", Title: "金额", Type: defined.FieldType_number, Aggregate: defined.Aggregate_sum, Conditionals: []defined.ConditionalFormat{
+			{Type: defined.Conditional_cell, Operator: "<", Value: "0", Style: &defined.CellStyle{Font: &defined.FontStyle{Color: "#FF0000"}}},
+			{Type: defined.Conditional_dataBar},
+		}},
+		{Name: "expireDate", Title: "过期日期"},
+	}
+	sheetOptions := defined.SheetOptions{Conditionals: []defined.ConditionalFormat{
+		{Type: defined.Conditional_formula, Formula: `{expireDate}<"2024-01-01"`, Style: &defined.CellStyle{Fill: "#FFC7CE"}},
+		{Type: defined.Conditional_colorScale, Field: "amount", MidColor: "#FFEB84"},
+	}}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	require.NoError(t, ecw.WriteData([]map[string]string{
+		{"name": "vip1", "amount": "-1", "expireDate": "2023-12-01"},
+		{"name": "b", "amount": "20", "expireDate": "2024-12-01"},
+	}))
+	require.NoError(t, ecw.Save())
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	formats, err := fd.GetConditionalFormats(excelrw.SheetDefault)
+	require.NoError(t, err)
+	require.Len(t, formats["A2:A3"], 1)
+	require.Equal(t, "text", formats["A2:A3"][0].Type)
+	require.Len(t, formats["B2:B3"], 3) // 不包含汇总行
+	require.Equal(t, "cell", formats["B2:B3"][0].Type)
+	require.Equal(t, "0", formats["B2:B3"][0].Value)
+	require.Equal(t, "data_bar", formats["B2:B3"][1].Type)
+	require.Equal(t, "3_color_scale", formats["B2:B3"][2].Type)
+	require.Len(t, formats["A2:C3"], 1)
+	require.Equal(t, `$C2<"2024-01-01"`, formats["A2:C3"][0].Criteria)
+}
+
+func TestWriteWithColumnWidth(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "width.xlsx")
+	fieldMetas := defined.FieldMetas{
+		{Name: "name", Title: "名称"},
+		{Name: "remark", Title: "备注", MaxWidth: 10},
+		{Name: "id", Title: "ID", MinWidth: 8},
+		{Name: "address", Title: "地址", Width: 30},
+	}
+	sheetOptions := defined.SheetOptions{WidthSampleRows: 3}
+	ecw := excelrw.NewExcelStreamWriter(context.Background(), filename).WithFieldMetas(fieldMetas).WithSheetOptions(sheetOptions)
+	pages := [][]map[string]string{
+		{{"name": "ab", "remark": "短", "id": "1", "address": "a"}},
+		{{"name": "中文名称", "remark": "很长很长很长很长的备注", "id": "2", "address": "b"}},
+	}
+	ecw.WithFetcher(func(loopIndex int) (rows []map[string]string, err error) {
+		if loopIndex <= len(pages) {
+			return pages[loopIndex-1], nil
+		}
+		return nil, nil
+	})
+	errChan, err := ecw.Run()
+	require.NoError(t, err)
+	require.NoError(t, <-errChan)
+
+	fd, err := excelize.OpenFile(filename)
+	require.NoError(t, err)
+	defer fd.Close()
+	sheet := excelrw.SheetDefault
+	rows, err := fd.GetRows(sheet)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	for col, expected := range map[string]float64{"A": 8, "B": 10, "C": 8, "D": 30} {
+		width, err := fd.GetColWidth(sheet, col)
+		require.NoError(t, err)
+		require.Equal(t, expected, width, col)
+	}
+}
@@ -0,0 +1,197 @@
+package excelrw
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	OptionsSheet = "_options" // 下拉选项超过 excel 内联长度限制时,选项写入该隐藏表单(名称已被使用时追加序号，例如：_options1)
+)
+
+// isProtected 是否启用表单保护(有锁定列时启用)
+func (ecw *ExcelStreamWriter) isProtected() bool {
+	return ecw.fieldMetas.HasLocked()
+}
+
+// firstEditableRow 第一个可编辑数据行行号
+func (ecw *ExcelStreamWriter) firstEditableRow() int {
+	if ecw.firstDataRow > 0 {
+		return ecw.firstDataRow
+	}
+	return ecw.lastHeaderRowNumber() + 1
+}
+
+// writeEditableRows 表单保护时在数据之后写入可编辑空行(未锁定列取消锁定),用于填写导入数据;
+// 有汇总行或模板尾部时不写入,汇总行、模板尾部紧跟数据,汇总公式只统计数据行
+func (ecw *ExcelStreamWriter) writeEditableRows() (err error) {
+	ecw.lastEditableRow = ecw.nextRowNumber - 1
+	if !ecw.isProtected() || ecw.fieldMetas.HasAggregate() || ecw.template != nil && len(ecw.template.tailRows) > 0 {
+		return nil
+	}
+	editableRows := ecw.sheetOptions.GetEditableRows()
+	for range editableRows {
+		rowNumber := ecw.nextRowNumber
+		if rowNumber > excelize.TotalRows {
+			break
+		}
+		row := make([]any, len(ecw.fieldMetas))
+		for i := range ecw.fieldMetas {
+			styleID, err := ecw.getCellStyleID(i, rowNumber)
+			if err != nil {
+				return err
+			}
+			row[i] = excelize.Cell{StyleID: styleID}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, rowNumber)
+		if err != nil {
+			return err
+		}
+		err = ecw.streamWriter.SetRow(cell, row)
+		if err != nil {
+			return err
+		}
+		ecw.nextRowNumber++
+	}
+	ecw.lastEditableRow = ecw.nextRowNumber - 1
+	return nil
+}
+
+// setDataValidations 设置允许值下拉列表,数据之后没有汇总行等内容时校验范围延伸到表单最后一行
+func (ecw *ExcelStreamWriter) setDataValidations() (err error) {
+	startRow := ecw.firstEditableRow()
+	endRow := excelize.TotalRows
+	if ecw.nextRowNumber-1 > ecw.lastEditableRow { // 数据之后有汇总行或模板尾部
+		endRow = ecw.lastEditableRow
+	}
+	if endRow < startRow {
+		return nil
+	}
+	for i, fieldMeta := range ecw.fieldMetas {
+		if len(fieldMeta.Options) == 0 {
+			continue
+		}
+		sqref, err := rangeRef(i+1, startRow, i+1, endRow)
+		if err != nil {
+			return err
+		}
+		dv := excelize.NewDataValidation(false)
+		dv.Sqref = sqref
+		err = ecw.setDropList(dv, fieldMeta.Options)
+		if err != nil {
+			err = errors.WithMessagef(err, "field:%s", fieldMeta.Name)
+			return err
+		}
+		dv.SetError(excelize.DataValidationErrorStyleStop, fieldMeta.Title, fmt.Sprintf("允许的值：%s", strings.Join(fieldMeta.Options, "、")))
+		err = ecw.fd.AddDataValidation(ecw.sheet, dv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setDropList 设置下拉列表,选项包含逗号或超过内联长度限制时,选项写入隐藏表单并引用
+func (ecw *ExcelStreamWriter) setDropList(dv *excelize.DataValidation, options []string) (err error) {
+	inline := !slices.ContainsFunc(options, func(option string) bool { return strings.Contains(option, ",") })
+	if inline {
+		err = dv.SetDropList(options)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, excelize.ErrDataValidationFormulaLength) {
+			return err
+		}
+	}
+	ref, err := ecw.writeOptionsColumn(options)
+	if err != nil {
+		return err
+	}
+	dv.SetSqrefDropList(ref)
+	return nil
+}
+
+// newOptionsSheet 创建选项隐藏表单,名称已被使用(如分表、多表单导出的表单名称)时追加序号
+func (ecw *ExcelStreamWriter) newOptionsSheet() (sheet string, err error) {
+	fd := ecw.fd
+	sheet = OptionsSheet
+	for i := 1; ; i++ {
+		index, err := fd.GetSheetIndex(sheet)
+		if err != nil {
+			return "", err
+		}
+		if index < 0 {
+			break
+		}
+		sheet = fmt.Sprintf("%s%d", OptionsSheet, i)
+	}
+	_, err = fd.NewSheet(sheet)
+	if err != nil {
+		return "", err
+	}
+	err = fd.SetSheetVisible(sheet, false)
+	if err != nil {
+		return "", err
+	}
+	return sheet, nil
+}
+
+// writeOptionsColumn 将选项写入隐藏表单的下一个空列,返回选项区域引用，例如：'_options'!$A$1:$A$10
+func (ecw *ExcelStreamWriter) writeOptionsColumn(options []string) (ref string, err error) {
+	fd := ecw.fd
+	if ecw.optionsSheet == "" {
+		ecw.optionsSheet, err = ecw.newOptionsSheet()
+		if err != nil {
+			return "", err
+		}
+	}
+	cols, err := fd.GetCols(ecw.optionsSheet)
+	if err != nil {
+		return "", err
+	}
+	col := len(cols) + 1
+	for i, option := range options {
+		cell, err := excelize.CoordinatesToCellName(col, i+1)
+		if err != nil {
+			return "", err
+		}
+		err = fd.SetCellStr(ecw.optionsSheet, cell, option)
+		if err != nil {
+			return "", err
+		}
+	}
+	startCell, err := excelize.CoordinatesToCellName(col, 1, true)
+	if err != nil {
+		return "", err
+	}
+	endCell, err := excelize.CoordinatesToCellName(col, len(options), true)
+	if err != nil {
+		return "", err
+	}
+	ref = fmt.Sprintf("'%s'!%s:%s", ecw.optionsSheet, startCell, endCell)
+	return ref, nil
+}
+
+// protectSheet 有锁定列时启用表单保护,锁定列及标题行不可编辑,允许筛选、调整行高列宽
+func (ecw *ExcelStreamWriter) protectSheet() (err error) {
+	if !ecw.isProtected() {
+		return nil
+	}
+	err = ecw.fd.ProtectSheet(ecw.sheet, &excelize.SheetProtectionOptions{
+		Password:            ecw.sheetOptions.ProtectPassword,
+		AutoFilter:          true,
+		FormatColumns:       true,
+		FormatRows:          true,
+		SelectLockedCells:   true,
+		SelectUnlockedCells: true,
+		Sort:                true,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
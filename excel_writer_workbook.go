@@ -0,0 +1,206 @@
+package excelrw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelWorkbookWriter 多表单写入,多个 ExcelStreamWriter 共享同一个文件,每个表单独立获取数据
+type ExcelWorkbookWriter struct {
+	context     context.Context
+	excelWriter *_ExcelWriter
+	filename    string
+	fd          *excelize.File
+	lock        sync.Mutex // excelize.File 非并发安全,写入文件时加锁(并行时只有获取数据并行)
+	writers     []*ExcelStreamWriter
+	parallel    bool
+	moveOldFile bool
+	appended    bool // 追加到已存在的文件,保存时保留已有表单
+}
+
+func NewExcelWorkbookWriter(ctx context.Context, filename string) (workbook *ExcelWorkbookWriter) {
+	workbook = &ExcelWorkbookWriter{
+		context:     ctx,
+		excelWriter: NewExcelWriter(),
+		filename:    filename,
+		moveOldFile: true,
+	}
+	return workbook
+}
+
+// WithAppendToExistsFile 追加到已存在的文件(保留已有表单),不移动旧文件
+func (workbook *ExcelWorkbookWriter) WithAppendToExistsFile() *ExcelWorkbookWriter {
+	workbook.moveOldFile = false
+	return workbook
+}
+
+// WithParallel 表单并行获取数据,默认按添加顺序依次导出
+func (workbook *ExcelWorkbookWriter) WithParallel(parallel bool) *ExcelWorkbookWriter {
+	workbook.parallel = parallel
+	return workbook
+}
+
+func (workbook *ExcelWorkbookWriter) WithDeleteFile(delay time.Duration, errorHandler func(err error)) *ExcelWorkbookWriter {
+	deleteFileAfter(workbook.filename, delay, errorHandler)
+	return workbook
+}
+
+func (workbook *ExcelWorkbookWriter) GetFilename() string {
+	return workbook.filename
+}
+
+// AddSheet 添加表单,返回表单写入器(设置字段元数据、数据获取器等)
+func (workbook *ExcelWorkbookWriter) AddSheet(sheet string) (ecw *ExcelStreamWriter, err error) {
+	for _, writer := range workbook.writers {
+		if strings.EqualFold(writer.sheet, sheet) {
+			err = errors.Errorf("sheet:%s already exists", sheet)
+			return nil, err
+		}
+	}
+	ecw = NewExcelStreamWriter(workbook.context, workbook.filename).WithSheet(sheet)
+	ecw.workbook = workbook
+	workbook.writers = append(workbook.writers, ecw)
+	return ecw, nil
+}
+
+// getFile 获取共享文件,并创建表单
+func (workbook *ExcelWorkbookWriter) getFile(sheet string) (fd *excelize.File, err error) {
+	if workbook.fd == nil {
+		workbook.appended = !workbook.moveOldFile && fileExists(workbook.filename)
+		workbook.fd, err = workbook.excelWriter.GetFile(workbook.filename, sheet, workbook.moveOldFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fd = workbook.fd
+	index, err := fd.GetSheetIndex(sheet)
+	if err != nil || index < 0 {
+		_, err = fd.NewSheet(sheet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fd, nil
+}
+
+// Run 执行导出,表单错误使用 SheetErrors 返回(某个表单失败不影响其它表单导出)
+func (workbook *ExcelWorkbookWriter) Run() (errChan chan error, err error) {
+	if len(workbook.writers) == 0 {
+		return nil, errors.New("sheets is required")
+	}
+	for _, ecw := range workbook.writers { // 创建表单及写入流(依次执行,避免并发修改文件)
+		err = ecw.init()
+		if err != nil {
+			err = errors.WithMessagef(err, "sheet:%s", ecw.sheet)
+			return nil, err
+		}
+	}
+	errChan = make(chan error)
+	go func() {
+		err := workbook.loop()
+		errChan <- err
+		close(errChan)
+	}()
+	return errChan, nil
+}
+
+func (workbook *ExcelWorkbookWriter) loop() (err error) {
+	sheetErrs := make([]error, len(workbook.writers))
+	if workbook.parallel {
+		var wg sync.WaitGroup
+		for i, ecw := range workbook.writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sheetErrs[i] = ecw.loop()
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i, ecw := range workbook.writers {
+			sheetErrs[i] = ecw.loop()
+		}
+	}
+	var errs SheetErrors
+	for i, ecw := range workbook.writers {
+		if sheetErrs[i] != nil {
+			errs = append(errs, SheetError{Sheet: ecw.sheet, Err: sheetErrs[i]})
+		}
+	}
+	err = workbook.Save()
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Save 保存文件,删除新建文件中未使用的默认表单
+func (workbook *ExcelWorkbookWriter) Save() (err error) {
+	fd := workbook.fd
+	defaultSheet := fd.GetSheetName(0)
+	used := workbook.appended // 追加到已存在的文件时保留已有表单
+	for _, ecw := range workbook.writers {
+		used = used || strings.EqualFold(ecw.sheet, defaultSheet)
+	}
+	if !used {
+		err = fd.DeleteSheet(defaultSheet)
+		if err != nil {
+			return err
+		}
+	}
+	index, err := fd.GetSheetIndex(workbook.writers[0].sheet)
+	if err != nil {
+		return err
+	}
+	fd.SetActiveSheet(index)
+	err = fd.Save()
+	if err != nil {
+		return err
+	}
+	err = fd.Close()
+	if err != nil {
+		return err
+	}
+	for _, ecw := range workbook.writers {
+		err = ecw.afterSave()
+		if err != nil {
+			err = errors.WithMessagef(err, "sheet:%s", ecw.sheet)
+			return err
+		}
+	}
+	return nil
+}
+
+// SheetError 表单导出错误
+type SheetError struct {
+	Sheet string `json:"sheet"`
+	Err   error  `json:"-"`
+}
+
+func (e SheetError) Error() string {
+	return fmt.Sprintf("sheet:%s,error:%s", e.Sheet, e.Err.Error())
+}
+
+func (e SheetError) Unwrap() error {
+	return e.Err
+}
+
+// SheetErrors 多个表单导出错误
+type SheetErrors []SheetError
+
+func (errs SheetErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
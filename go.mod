@@ -10,6 +10,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/hoisie/mustache v0.0.0-20160804235033-6375acf62c69
 	github.com/pkg/errors v0.9.1
+	github.com/richardlehane/mscfb v1.0.4
 	github.com/spf13/cast v1.10.0
 	github.com/stretchr/testify v1.11.0
 	github.com/suifengpiao14/apihttpprotocol v0.0.16
@@ -21,6 +22,7 @@ require (
 	github.com/tidwall/gjson v1.18.0
 	github.com/tidwall/sjson v1.2.5
 	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/text v0.22.0
 )
 
 require (
@@ -63,7 +65,6 @@ require (
 	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/redis/go-redis/v9 v9.7.0 // indirect
-	github.com/richardlehane/mscfb v1.0.4 // indirect
 	github.com/richardlehane/msoleps v1.0.4 // indirect
 	github.com/rogpeppe/go-internal v1.11.0 // indirect
 	github.com/smarty/assertions v1.16.0 // indirect
@@ -83,7 +84,6 @@ require (
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
-	golang.org/x/text v0.22.0 // indirect
 	google.golang.org/protobuf v1.36.8 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,98 @@
+package excelrw
+
+import (
+	"strings"
+
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/tidwall/gjson"
+)
+
+// MakeRecords 将接口返回的一条数据转换为记录,嵌套路径(如 buyer.address.city、items.#.sku)的值以路径为键存入记录;
+// flattenPath 不为空时,按该路径下的数组展开为多条记录,父级列重复写入每条记录
+func MakeRecords(row gjson.Result, fieldMetas defined.FieldMetas, flattenPath string) (records []map[string]string) {
+	parent := make(map[string]string)
+	row.ForEach(func(key, value gjson.Result) bool {
+		parent[key.String()] = value.String()
+		return true
+	})
+	paths := fieldMetas.NestedPaths()
+	if flattenPath == "" {
+		for _, path := range paths {
+			parent[path] = getPathValue(row, path)
+		}
+		return []map[string]string{parent}
+	}
+
+	items := row.Get(flattenPath).Array()
+	if len(items) == 0 { // 没有子元素,保留父级记录
+		items = []gjson.Result{{}}
+	}
+	records = make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		record := make(map[string]string, len(parent)+len(paths))
+		for k, v := range parent {
+			record[k] = v
+		}
+		for _, path := range paths {
+			subPath, ok := trimFlattenPath(path, flattenPath)
+			if ok {
+				record[path] = getPathValue(item, subPath)
+				continue
+			}
+			record[path] = getPathValue(row, path)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// trimFlattenPath 将 items.#.sku、items.sku 转换为数组元素内的路径 sku
+func trimFlattenPath(path string, flattenPath string) (subPath string, ok bool) {
+	subPath, ok = strings.CutPrefix(path, flattenPath+".")
+	if !ok {
+		return "", false
+	}
+	subPath = strings.TrimPrefix(subPath, "#.")
+	return subPath, subPath != ""
+}
+
+// getPathValue 获取路径值,标量数组(如 items.#.sku)使用逗号连接
+func getPathValue(row gjson.Result, path string) (value string) {
+	result := row.Get(path)
+	if !result.IsArray() {
+		return result.String()
+	}
+	values := make([]string, 0)
+	for _, item := range result.Array() {
+		if item.IsObject() || item.IsArray() {
+			return result.String()
+		}
+		values = append(values, item.String())
+	}
+	return strings.Join(values, ",")
+}
+
+// InferFieldMetas 按接口返回数据的字段顺序推断列,只返回 exists 中不存在的列
+func InferFieldMetas(rows []gjson.Result, exists defined.FieldMetas, humanizeTitle bool) (fieldMetas defined.FieldMetas) {
+	names := make(map[string]bool)
+	for _, fieldMeta := range exists {
+		names[fieldMeta.Name] = true
+	}
+	fieldMetas = make(defined.FieldMetas, 0)
+	for _, row := range rows {
+		row.ForEach(func(key, value gjson.Result) bool {
+			name := key.String()
+			if names[name] {
+				return true
+			}
+			names[name] = true
+			title := name
+			if humanizeTitle {
+				title = defined.HumanizeTitle(name)
+			}
+			fieldMetas = append(fieldMetas, defined.FieldMeta{Name: name, Title: title})
+			return true
+		})
+	}
+	return fieldMetas
+}
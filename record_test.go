@@ -0,0 +1,50 @@
+package excelrw_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/tidwall/gjson"
+)
+
+var orderJson = `{"orderId":"1001","buyer":{"name":"张三","address":{"city":"深圳"}},"items":[{"sku":"a","qty":1},{"sku":"b","qty":2}]}`
+
+func TestMakeRecords(t *testing.T) {
+	fieldMetas := defined.FieldMetas{
+		{Name: "orderId", Title: "订单号"},
+		{Name: "buyer.address.city", Title: "城市"},
+		{Name: "{{buyer.name}}({{buyer.address.city}})", Title: "买家"},
+		{Name: "items.#.sku", Title: "SKU"},
+	}
+	row := gjson.Parse(orderJson)
+	t.Run("nested", func(t *testing.T) {
+		records := excelrw.MakeRecords(row, fieldMetas, "")
+		require.Len(t, records, 1)
+		require.Equal(t, "深圳", records[0]["buyer.address.city"])
+		require.Equal(t, "a,b", records[0]["items.#.sku"])
+		require.Equal(t, "张三(深圳)", fieldMetas[2].GetValue(1, records[0]))
+	})
+	t.Run("flatten", func(t *testing.T) {
+		records := excelrw.MakeRecords(row, fieldMetas, "items")
+		require.Len(t, records, 2)
+		require.Equal(t, "1001", records[1]["orderId"])
+		require.Equal(t, "深圳", records[1]["buyer.address.city"])
+		require.Equal(t, "b", records[1]["items.#.sku"])
+	})
+}
+
+func TestInferFieldMetas(t *testing.T) {
+	rows := gjson.Parse(`[{"orderId":"1","createTime":"2025-10-29","user_id":"7"},{"orderId":"2","cityName":"深圳"}]`).Array()
+	fieldMetas := excelrw.InferFieldMetas(rows, nil, true)
+	titles := make([]string, 0)
+	for _, fieldMeta := range fieldMetas {
+		titles = append(titles, fieldMeta.Title)
+	}
+	require.Equal(t, []string{"Order ID", "Create Time", "User ID", "City Name"}, titles)
+
+	newFieldMetas := excelrw.InferFieldMetas(gjson.Parse(`[{"orderId":"3","remark":"x"}]`).Array(), fieldMetas, false)
+	require.Len(t, newFieldMetas, 1)
+	require.Equal(t, "remark", newFieldMetas[0].Title)
+}
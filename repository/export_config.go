@@ -36,11 +36,13 @@ var Export_config_table = sqlbuilder.NewTableConfig("t_export_config").AddColumn
 	sqlbuilder.NewColumn("Fpage_size_path", sqlbuilder.GetField(NewPageSizePath)),
 	sqlbuilder.NewColumn("Fpage_size", sqlbuilder.GetField(NewPageSize)),
 	sqlbuilder.NewColumn("Fdata_path", sqlbuilder.GetField(NewDataPath)),
+	sqlbuilder.NewColumn("Fflatten_path", sqlbuilder.GetField(NewFlattenPath)),
 	sqlbuilder.NewColumn("Fdynamic_script", sqlbuilder.GetField(NewDynamicScript)),
 	sqlbuilder.NewColumn("Fbusiness_code_path", sqlbuilder.GetField(NewBusinessCodePath)),
 	sqlbuilder.NewColumn("Fbusiness_ok_code", sqlbuilder.GetField(NewBusinessOkCode)),
 	sqlbuilder.NewColumn("Ffilename_tpl", sqlbuilder.GetField(NewFilenameTpl)),
 	sqlbuilder.NewColumn("Ffield_metas", sqlbuilder.GetField(NewFieldMetas)),
+	sqlbuilder.NewColumn("Fsheet_options", sqlbuilder.GetField(NewSheetOptions)),
 	sqlbuilder.NewColumn("Finterval", sqlbuilder.GetField(NewInterval)),
 	//sqlbuilder.NewColumn("Ftask_deal_max_time", sqlbuilder.GetField(NewTaskDealMaxTime)),
 	sqlbuilder.NewColumn("Fdelete_file_delay", sqlbuilder.GetField(NewDeleteFileDelay)),
@@ -85,10 +87,12 @@ type ExportConfigModel struct {
 	PageSizePath      string `gorm:"column:pageSizePath" xorm:"'pageSizePath'" db:"pageSizePath" json:"pageSizePath"`                     // 每页数量参数路径，例如：$.data.pageSize
 	PageSize          int    `gorm:"column:pageSize" xorm:"'pageSize'" db:"pageSize" json:"pageSize"`                                     // 每页数量，例如：10
 	DataPath          string `gorm:"column:dataPath" xorm:"'dataPath'" db:"dataPath" json:"dataPath"`                                     // 数据路径，例如：$.data.list
+	FlattenPath       string `gorm:"column:flattenPath" xorm:"'flattenPath'" db:"flattenPath" json:"flattenPath"`                         // 展开数组路径，例如：items
 	BusinessCodePath  string `gorm:"column:businessCodePath" xorm:"'businessCodePath'" db:"businessCodePath" json:"businessCodePath"`     // 业务成功标识路径，例如：$.code
 	BusinessOkCode    string `gorm:"column:businessOkCode" xorm:"'businessOkCode'" db:"businessOkCode" json:"businessOkCode"`             // 业务成功标识值
 	FilenameTpl       string `gorm:"column:filenameTpl" xorm:"'filenameTpl'" db:"filenameTpl" json:"filenameTpl"`                         // 导出文件全称如 /static/export/{{fielname}}.xlsx
 	FieldMetas        string `gorm:"column:fieldMetas" xorm:"'fieldMetas'" db:"fieldMetas" json:"fieldMetas"`                             // 字段映射信息，例如：[{"name":"id","title":"title"}]
+	SheetOptions      string `gorm:"column:sheetOptions" xorm:"'sheetOptions'" db:"sheetOptions" json:"sheetOptions"`                     // 导出表格选项，例如：{"inferFieldPolicy":"append"}
 	TaskDealMaxTime   string `gorm:"column:taskDealMaxTime" xorm:"'taskDealMaxTime'" db:"taskDealMaxTime" json:"taskDealMaxTime"`         // 任务处理最大时间，例如：10s
 	Interval          string `gorm:"column:interval" xorm:"'interval'" db:"interval" json:"interval"`                                     // 间隔时间，例如：10s
 	DeleteFileDelay   string `gorm:"column:deleteFileDelay" xorm:"'deleteFileDelay'" db:"deleteFileDelay" json:"deleteFileDelay"`         // 删除文件延迟时间，例如：10s
@@ -129,6 +133,15 @@ func (m ExportConfigModel) ParseFieldMetas() (fieldMetas defined.FieldMetas, err
 	return fieldMetas, nil
 }
 
+func (m ExportConfigModel) ParseSheetOptions() (sheetOptions defined.SheetOptions, err error) {
+	err = sheetOptions.Unmarshal(m.SheetOptions)
+	if err != nil {
+		err = errors.WithMessagef(err, "json string:%s", m.SheetOptions)
+		return sheetOptions, err
+	}
+	return sheetOptions, nil
+}
+
 const (
 	Duration_zero = "-1"
 )
@@ -208,13 +221,19 @@ type DynamicFn struct {
 	RequestFormatFn  defined.RequestFormatFn
 	ResponseFormatFn defined.ResponseFormatFn
 	RecordFormatFn   defined.RecordFormatFn
+	RecordsFormatFn  defined.RecordsFormatFn // 同一个js函数(recordFormatFn),支持返回null(过滤)、数组(展开)
 }
 
 func (m ExportConfigModel) ParseDynamicScript() (dynamicFn DynamicFn, err error) {
-	if m.DynamicScript == "" {
+	return parseDynamicScript(m.DynamicScript)
+}
+
+// parseDynamicScript 解析动态脚本中定义的函数,未定义的函数为nil
+func parseDynamicScript(dynamicScript string) (dynamicFn DynamicFn, err error) {
+	if dynamicScript == "" {
 		return dynamicFn, nil
 	}
-	jsvm, err := dynamichook.ParseJSVM(m.DynamicScript)
+	jsvm, err := dynamichook.ParseJSVM(dynamicScript)
 	if err != nil {
 		return dynamicFn, err
 	}
@@ -230,6 +249,18 @@ func (m ExportConfigModel) ParseDynamicScript() (dynamicFn DynamicFn, err error)
 	}
 	dynamicFn.RecordFormatFn = recordFormatFn
 
+	recordsFormatFn, err := jsvm.RecordsFormatFn(RecordFormatFnName)
+	if err != nil {
+		if errors.Is(err, dynamichook.ErrorJSNotFound) {
+			err = nil
+			recordsFormatFn = nil // 未定义则不处理,避免每条记录都调用默认函数
+		}
+	}
+	if err != nil {
+		return dynamicFn, err
+	}
+	dynamicFn.RecordsFormatFn = recordsFormatFn
+
 	requestFormatFn, err := jsvm.RequestFormatFn(RequestFormatFnName)
 	if err != nil {
 		if errors.Is(err, dynamichook.ErrorJSNotFound) {
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/suifengpiao14/sqlbuilder"
+)
+
+var Export_dict_table = sqlbuilder.NewTableConfig("t_export_dict").AddColumns(
+	sqlbuilder.NewColumn("Fid", sqlbuilder.GetField(NewId)),
+	sqlbuilder.NewColumn("Fdict_key", sqlbuilder.GetField(NewDictKey)),
+	sqlbuilder.NewColumn("Ftitle", sqlbuilder.GetField(NewTitle)),
+	sqlbuilder.NewColumn("Fitems", sqlbuilder.GetField(NewDictItems)),
+	sqlbuilder.NewColumn("Fcreated_at", sqlbuilder.GetField(NewCreatedAt)),
+	sqlbuilder.NewColumn("Fupdated_at", sqlbuilder.GetField(NewUpdatedAt)),
+).AddIndexs(
+	sqlbuilder.Index{
+		Unique: true,
+		ColumnNames: func(table sqlbuilder.TableConfig) (columnNames []string) {
+			columnNames = []string{
+				table.GetDBNameByFieldNameMust(sqlbuilder.GetFieldName(NewDictKey)),
+			}
+			return columnNames
+		},
+	},
+)
+
+// ExportDictModel 共享值映射字典,FieldMeta.DictKey 引用
+type ExportDictModel struct {
+	Id        int    `gorm:"column:id" json:"id"`
+	DictKey   string `gorm:"column:dictKey" json:"dictKey"`
+	Title     string `gorm:"column:title" json:"title"`
+	Items     string `gorm:"column:items" json:"items"` // 字典项，例如：{"1":"待使用","2":"已使用"}
+	CreatedAt string `gorm:"column:createdAt" json:"createdAt"`
+	UpdatedAt string `gorm:"column:updatedAt" json:"updatedAt"`
+}
+
+func (m ExportDictModel) ParseItems() (items map[string]string, err error) {
+	items = make(map[string]string)
+	if m.Items == "" {
+		return items, nil
+	}
+	err = json.Unmarshal([]byte(m.Items), &items)
+	if err != nil {
+		err = errors.WithMessagef(err, "dictKey:%s,json string:%s", m.DictKey, m.Items)
+		return nil, err
+	}
+	return items, nil
+}
+
+type ExportDictModels []ExportDictModel
+
+func (ms ExportDictModels) ToMap() (dicts map[string]map[string]string, err error) {
+	dicts = make(map[string]map[string]string)
+	for _, m := range ms {
+		items, err := m.ParseItems()
+		if err != nil {
+			return nil, err
+		}
+		dicts[m.DictKey] = items
+	}
+	return dicts, nil
+}
+
+type ExportDictRepository struct {
+	table sqlbuilder.TableConfig
+}
+
+func NewExportDictRepository(tableConfig sqlbuilder.TableConfig) ExportDictRepository {
+	fieldNames := Export_dict_table.Columns.Fields().Names()        //从内置表中提取必备字段名
+	err := tableConfig.Columns.CheckMissOutFieldName(fieldNames...) //检测传入表配置中是否缺失内置字段名，如果有则panic退出
+	if err != nil {
+		panic(err)
+	}
+	tableConfig = tableConfig.AddIndexs(Export_dict_table.Indexs...) //合并索引配置
+
+	s := ExportDictRepository{
+		table: tableConfig,
+	}
+	return s
+}
+
+func (s ExportDictRepository) GetByDictKeys(dictKeys ...string) (models ExportDictModels, err error) {
+	fs := sqlbuilder.Fields{
+		NewDictKey("").SetRequired(true).AppendWhereFn(sqlbuilder.ValueFnForward).Apply(func(f *sqlbuilder.Field, fs ...*sqlbuilder.Field) {
+			f.ValueFns.ResetSetValueFn(func(inputValue any, f *sqlbuilder.Field, fs ...*sqlbuilder.Field) (any, error) {
+				return dictKeys, nil
+			})
+		}).SetDelayApply(func(f *sqlbuilder.Field, fs ...*sqlbuilder.Field) {
+			columns := f.GetTable().Columns.DbNameWithAlias().AsAny()
+			f.SetSelectColumns(columns...)
+		}),
+	}
+	err = s.table.Repository().All(&models, fs)
+	if err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// DictLoader 生成字典加载函数,用于 FieldMetas.LoadDicts
+func (s ExportDictRepository) DictLoader() defined.DictLoader {
+	return func(dictKeys ...string) (dicts map[string]map[string]string, err error) {
+		models, err := s.GetByDictKeys(dictKeys...)
+		if err != nil {
+			return nil, err
+		}
+		return models.ToMap()
+	}
+}
@@ -0,0 +1,15 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/repository"
+)
+
+func TestExportDictTableDDL(t *testing.T) {
+	ddl, err := repository.Export_dict_table.GenerateDDL()
+	require.NoError(t, err)
+	fmt.Println(ddl)
+}
@@ -57,6 +57,15 @@ func NewTimeout(timeout string) (field *sqlbuilder.Field) {
 func NewSize(size int) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewIntField(size, "size", "文件大小", 0)
 }
+func NewTotal(total int) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewIntField(total, "total", "总行数", 0)
+}
+func NewSucceeded(succeeded int) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewIntField(succeeded, "succeeded", "成功行数", 0)
+}
+func NewFailed(failed int) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewIntField(failed, "failed", "失败行数", 0)
+}
 func NewRemark(remark string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(remark, "remark", "备注", 0)
 }
@@ -118,6 +127,9 @@ func NewPageSize(pageSize string) (field *sqlbuilder.Field) {
 func NewDataPath(dataPath string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(dataPath, "dataPath", "数据路径，例如：data.list", 0)
 }
+func NewFlattenPath(flattenPath string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(flattenPath, "flattenPath", "展开数组路径(每个元素输出一行)，例如：items", 0)
+}
 func NewBusinessCodePath(businessCodePath string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(businessCodePath, "businessCodePath", "业务成功标识路径，例如：code", 0)
 }
@@ -133,6 +145,10 @@ func NewFieldMetas(fieldMetas string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(fieldMetas, "fieldMetas", `字段映射信息，例如：[{"name":"id","title":"title"}]`, 0)
 }
 
+func NewSheetOptions(sheetOptions string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(sheetOptions, "sheetOptions", `导出表格选项，例如：{"inferFieldPolicy":"append"}`, int(sqlbuilder.Str_Text))
+}
+
 func NewInterval(interval string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(interval, "interval", "间隔时间，例如：10s", 0)
 }
@@ -146,6 +162,42 @@ func NewDeleteFileDelay(deleteFileDelay string) (field *sqlbuilder.Field) {
 func NewDynamicScript(dynamicScript string) (field *sqlbuilder.Field) {
 	return sqlbuilder.NewStringField(dynamicScript, "dynamicScript", "动态脚本", int(sqlbuilder.Str_Text))
 }
+func NewDictKey(dictKey string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(dictKey, "dictKey", "字典键", 0)
+}
+func NewTitle(title string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(title, "title", "标题", 0)
+}
+func NewDictItems(items string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(items, "items", `字典项，例如：{"1":"待使用","2":"已使用"}`, int(sqlbuilder.Str_Text))
+}
+func NewRowsPath(rowsPath string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(rowsPath, "rowsPath", "每批数据在请求体中的路径，例如：data.list", 0)
+}
+func NewBatchSize(batchSize string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(batchSize, "batchSize", "每批提交行数", 0)
+}
+func NewMaxRetries(maxRetries string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(maxRetries, "maxRetries", "每批失败重试次数", 0)
+}
+func NewHeaderRow(headerRow string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(headerRow, "headerRow", "标题行行号(从1开始,0表示自动定位)", 0)
+}
+func NewFailedRowsPath(failedRowsPath string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(failedRowsPath, "failedRowsPath", "批次中失败行列表路径，例如：data.failed", 0)
+}
+func NewFailedIndexPath(failedIndexPath string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(failedIndexPath, "failedIndexPath", "失败行在批次中的序号路径，例如：index", 0)
+}
+func NewFailedMessagePath(failedMessagePath string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(failedMessagePath, "failedMessagePath", "失败行错误信息路径，例如：message", 0)
+}
+func NewValidateRules(validateRules string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(validateRules, "validateRules", `字段校验规则，例如：{"phone":"required,len=11"}`, int(sqlbuilder.Str_Text))
+}
+func NewUnmergeCell(unmergeCell string) (field *sqlbuilder.Field) {
+	return sqlbuilder.NewStringField(unmergeCell, "unmergeCell", "是否展开合并单元格(值填充到每个单元格)，例如：1", 0)
+}
 func NewCreatedAt(createdAt string) (field *sqlbuilder.Field) {
 	return commonlanguage.NewCreatedAt(createdAt)
 }
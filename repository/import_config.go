@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/suifengpiao14/httpraw"
+	"github.com/suifengpiao14/sqlbuilder"
+)
+
+var Import_config_table = sqlbuilder.NewTableConfig("t_import_config").AddColumns(
+	sqlbuilder.NewColumn("Fapp_id", sqlbuilder.GetField(NewAppId)),
+	sqlbuilder.NewColumn("Fconfig_key", sqlbuilder.GetField(NewConfigKey)),
+	sqlbuilder.NewColumn("Fproxy_request_tpl", sqlbuilder.GetField(NewProxyRequestTpl)),
+	sqlbuilder.NewColumn("Frows_path", sqlbuilder.GetField(NewRowsPath)),
+	sqlbuilder.NewColumn("Fbatch_size", sqlbuilder.GetField(NewBatchSize)),
+	sqlbuilder.NewColumn("Fmax_retries", sqlbuilder.GetField(NewMaxRetries)),
+	sqlbuilder.NewColumn("Fheader_row", sqlbuilder.GetField(NewHeaderRow)),
+	sqlbuilder.NewColumn("Fbusiness_code_path", sqlbuilder.GetField(NewBusinessCodePath)),
+	sqlbuilder.NewColumn("Fbusiness_ok_code", sqlbuilder.GetField(NewBusinessOkCode)),
+	sqlbuilder.NewColumn("Ffailed_rows_path", sqlbuilder.GetField(NewFailedRowsPath)),
+	sqlbuilder.NewColumn("Ffailed_index_path", sqlbuilder.GetField(NewFailedIndexPath)),
+	sqlbuilder.NewColumn("Ffailed_message_path", sqlbuilder.GetField(NewFailedMessagePath)),
+	sqlbuilder.NewColumn("Ffield_metas", sqlbuilder.GetField(NewFieldMetas)),
+	sqlbuilder.NewColumn("Fvalidate_rules", sqlbuilder.GetField(NewValidateRules)),
+	sqlbuilder.NewColumn("Funmerge_cell", sqlbuilder.GetField(NewUnmergeCell)),
+	sqlbuilder.NewColumn("Fdynamic_script", sqlbuilder.GetField(NewDynamicScript)),
+).AddIndexs(
+	sqlbuilder.Index{
+		Unique: true,
+		ColumnNames: func(table sqlbuilder.TableConfig) (columnNames []string) {
+			columnNames = []string{
+				table.GetDBNameByFieldNameMust(sqlbuilder.GetFieldName(NewConfigKey)),
+			}
+			return columnNames
+		},
+	},
+)
+
+type ImportConfigRepository struct {
+	table sqlbuilder.TableConfig
+}
+
+func NewImportConfigRepository(tableConfig sqlbuilder.TableConfig) ImportConfigRepository {
+	fieldNames := Import_config_table.Columns.Fields().Names()      //从内置表中提取必备字段名
+	err := tableConfig.Columns.CheckMissOutFieldName(fieldNames...) //检测传入表配置中是否缺失内置字段名，如果有则panic退出
+	if err != nil {
+		panic(err)
+	}
+	tableConfig = tableConfig.AddIndexs(Import_config_table.Indexs...) //合并索引配置
+
+	s := ImportConfigRepository{
+		table: tableConfig,
+	}
+	return s
+}
+
+// ImportConfigModel 导入配置模型结构体，用于解析配置信息,这里gorm:"column:xxx"是固定不变的(查询语句会使用别名转换字段)
+type ImportConfigModel struct {
+	AppId             string `gorm:"column:appId" xorm:"'appId'" db:"appId" json:"appId"`                                                 // 应用ID
+	ConfigKey         string `gorm:"column:configKey" xorm:"'configKey'" db:"configKey" json:"configKey"`                                 // 配置键
+	ProxyRequestTpl   string `gorm:"column:proxyRequestTpl" xorm:"'proxyRequestTpl'" db:"proxyRequestTpl" json:"proxyRequestTpl"`         // 代理提交数据请求模板
+	RowsPath          string `gorm:"column:rowsPath" xorm:"'rowsPath'" db:"rowsPath" json:"rowsPath"`                                     // 每批数据在请求体中的路径，例如：data.list
+	BatchSize         int    `gorm:"column:batchSize" xorm:"'batchSize'" db:"batchSize" json:"batchSize"`                                 // 每批提交行数，例如：100
+	MaxRetries        int    `gorm:"column:maxRetries" xorm:"'maxRetries'" db:"maxRetries" json:"maxRetries"`                             // 每批失败重试次数，例如：2
+	HeaderRow         int    `gorm:"column:headerRow" xorm:"'headerRow'" db:"headerRow" json:"headerRow"`                                 // 标题行行号，0表示自动定位
+	BusinessCodePath  string `gorm:"column:businessCodePath" xorm:"'businessCodePath'" db:"businessCodePath" json:"businessCodePath"`     // 业务成功标识路径，例如：$.code
+	BusinessOkCode    string `gorm:"column:businessOkCode" xorm:"'businessOkCode'" db:"businessOkCode" json:"businessOkCode"`             // 业务成功标识值
+	FailedRowsPath    string `gorm:"column:failedRowsPath" xorm:"'failedRowsPath'" db:"failedRowsPath" json:"failedRowsPath"`             // 批次中失败行列表路径，例如：data.failed
+	FailedIndexPath   string `gorm:"column:failedIndexPath" xorm:"'failedIndexPath'" db:"failedIndexPath" json:"failedIndexPath"`         // 失败行在批次中的序号路径，例如：index
+	FailedMessagePath string `gorm:"column:failedMessagePath" xorm:"'failedMessagePath'" db:"failedMessagePath" json:"failedMessagePath"` // 失败行错误信息路径，例如：message
+	FieldMetas        string `gorm:"column:fieldMetas" xorm:"'fieldMetas'" db:"fieldMetas" json:"fieldMetas"`                             // 字段映射信息，例如：[{"name":"id","title":"title"}]
+	ValidateRules     string `gorm:"column:validateRules" xorm:"'validateRules'" db:"validateRules" json:"validateRules"`                 // 字段校验规则(覆盖字段元数据中的 validate)，例如：{"phone":"required,len=11"}
+	UnmergeCell       string `gorm:"column:unmergeCell" xorm:"'unmergeCell'" db:"unmergeCell" json:"unmergeCell"`                         // 是否展开合并单元格，例如：1
+	DynamicScript     string `gorm:"column:dynamicScript" xorm:"'dynamicScript'" db:"dynamicScript" json:"dynamicScript"`                 // 动态脚本
+}
+
+// ParseFieldMetas 解析字段元数据,并合并字段校验规则
+func (m ImportConfigModel) ParseFieldMetas() (fieldMetas defined.FieldMetas, err error) {
+	fieldMetas = make(defined.FieldMetas, 0)
+	if m.FieldMetas != "" {
+		err = fieldMetas.Unmarshal(m.FieldMetas)
+		if err != nil {
+			err = errors.WithMessagef(err, "json string:%s", m.FieldMetas)
+			return nil, err
+		}
+	}
+	rules, err := m.ParseValidateRules()
+	if err != nil {
+		return nil, err
+	}
+	for name, rule := range rules {
+		found := false
+		for i := range fieldMetas {
+			if fieldMetas[i].Name == name {
+				fieldMetas[i].Validate = rule
+				found = true
+			}
+		}
+		if !found {
+			err = errors.Errorf("validateRules field:%s not found in fieldMetas", name)
+			return nil, err
+		}
+	}
+	return fieldMetas, nil
+}
+
+// ParseValidateRules 解析字段校验规则,字段名=>validator 规则
+func (m ImportConfigModel) ParseValidateRules() (rules map[string]string, err error) {
+	rules = make(map[string]string)
+	if m.ValidateRules == "" {
+		return rules, nil
+	}
+	err = json.Unmarshal([]byte(m.ValidateRules), &rules)
+	if err != nil {
+		err = errors.WithMessagef(err, "json string:%s", m.ValidateRules)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// IsUnmergeCell 是否展开合并单元格
+func (m ImportConfigModel) IsUnmergeCell() bool {
+	return cast.ToBool(m.UnmergeCell)
+}
+
+func (m ImportConfigModel) ParseDynamicScript() (dynamicFn DynamicFn, err error) {
+	return parseDynamicScript(m.DynamicScript)
+}
+
+func (m ImportConfigModel) RenderRequestDTO(context ...any) (rDTO *httpraw.RequestDTO, err error) {
+	rDTO, err = httpraw.RenderRequestDTO(m.ProxyRequestTpl, context...)
+	if err != nil {
+		err = errors.WithMessagef(err, "ImportConfigModel.RenderRequestDTO")
+		return nil, err
+	}
+	return rDTO, nil
+}
+
+type ImportConfigRepositoryGetIn struct {
+	ConfigKey   string
+	ExtraFields sqlbuilder.Fields
+}
+
+func (in ImportConfigRepositoryGetIn) Fields() sqlbuilder.Fields {
+	fs := sqlbuilder.Fields{
+		NewConfigKey(in.ConfigKey).SetRequired(true).AppendWhereFn(sqlbuilder.ValueFnForward).SetDelayApply(func(f *sqlbuilder.Field, fs ...*sqlbuilder.Field) {
+			fieldNames := Import_config_table.Columns.Fields().Names()
+			columns := f.GetTable().Columns.FilterByFieldName(fieldNames...)
+			columnsWithAlais := columns.DbNameWithAlias().AsAny()
+			f.SetSelectColumns(columnsWithAlais...)
+		}),
+	}
+	fs = fs.Add(in.ExtraFields...)
+	return fs
+}
+
+func (s ImportConfigRepository) GetMust(in ImportConfigRepositoryGetIn) (model *ImportConfigModel, err error) {
+	model = &ImportConfigModel{}
+	err = s.table.Repository().FirstMustExists(model, in.Fields())
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
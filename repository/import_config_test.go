@@ -0,0 +1,30 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/repository"
+)
+
+func TestImportConfigTableDDL(t *testing.T) {
+	ddl, err := repository.Import_config_table.GenerateDDL()
+	require.NoError(t, err)
+	fmt.Println(ddl)
+}
+
+func TestImportConfigParseFieldMetas(t *testing.T) {
+	config := repository.ImportConfigModel{
+		FieldMetas:    `[{"name":"phone","title":"手机号","validate":"required"},{"name":"email","title":"邮箱"}]`,
+		ValidateRules: `{"phone":"required,len=11","email":"omitempty,email"}`,
+	}
+	fieldMetas, err := config.ParseFieldMetas()
+	require.NoError(t, err)
+	require.Equal(t, "required,len=11", fieldMetas[0].Validate)
+	require.Equal(t, "omitempty,email", fieldMetas[1].Validate)
+
+	config.ValidateRules = `{"name":"required"}`
+	_, err = config.ParseFieldMetas()
+	require.Error(t, err)
+}
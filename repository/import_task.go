@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"github.com/spf13/cast"
+	"github.com/suifengpiao14/sqlbuilder"
+)
+
+var Import_task_table = sqlbuilder.NewTableConfig("t_import_task").AddColumns(
+	sqlbuilder.NewColumn("id", sqlbuilder.GetField(NewId)),
+	sqlbuilder.NewColumn("config_key", sqlbuilder.GetField(NewConfigKey)),
+	sqlbuilder.NewColumn("app_id", sqlbuilder.GetField(NewAppId)),
+	sqlbuilder.NewColumn("creator_id", sqlbuilder.GetField(NewCreatorId)),
+	sqlbuilder.NewColumn("filename", sqlbuilder.GetField(NewFilename)),
+	sqlbuilder.NewColumn("status", sqlbuilder.GetField(NewStatus)),
+	sqlbuilder.NewColumn("total", sqlbuilder.GetField(NewTotal)),
+	sqlbuilder.NewColumn("succeeded", sqlbuilder.GetField(NewSucceeded)),
+	sqlbuilder.NewColumn("failed", sqlbuilder.GetField(NewFailed)),
+	sqlbuilder.NewColumn("url", sqlbuilder.GetField(NewUrl)),
+	sqlbuilder.NewColumn("remark", sqlbuilder.GetField(NewRemark)),
+	sqlbuilder.NewColumn("created_at", sqlbuilder.GetField(NewCreatedAt)),
+	sqlbuilder.NewColumn("updated_at", sqlbuilder.GetField(NewUpdatedAt)),
+).AddIndexs(
+	sqlbuilder.Index{
+		Unique: true,
+		ColumnNames: func(table sqlbuilder.TableConfig) (columnNames []string) {
+			columnNames = []string{
+				table.GetDBNameByFieldNameMust(sqlbuilder.GetFieldName(NewId)),
+			}
+			return columnNames
+		},
+	},
+)
+
+const (
+	Task_status_importing = "importing"
+
+	ImportDependTaskId_prefix = "import:" // 回调请求依赖导入任务时的任务ID前缀(与导出任务ID区分)
+)
+
+// ImportDependTaskId 回调请求依赖导入任务时使用的任务ID
+func ImportDependTaskId(id int) string {
+	return ImportDependTaskId_prefix + cast.ToString(id)
+}
+
+type ImportTaskModel struct {
+	Id        int    `gorm:"column:id"  json:"id"`
+	ConfigKey string `gorm:"column:configKey"  json:"configKey"`
+	AppId     string `gorm:"column:appId"  json:"appId"`
+	CreatorId string `gorm:"column:creatorId"  json:"creatorId"`
+	Filename  string `gorm:"column:filename"  json:"filename"`
+	Status    string `gorm:"column:status"  json:"status"`
+	Total     int    `gorm:"column:total"  json:"total"`
+	Succeeded int    `gorm:"column:succeeded"  json:"succeeded"`
+	Failed    int    `gorm:"column:failed"  json:"failed"`
+	Url       string `gorm:"column:url"  json:"url"` // 导入结果文件(标注错误的文件)下载地址
+	Remark    string `gorm:"column:remark"  json:"remark"`
+	CreatedAt string `gorm:"column:createdAt"  json:"createdAt"`
+	UpdatedAt string `gorm:"column:updatedAt"  json:"updatedAt"`
+}
+
+type ImportTaskModels []ImportTaskModel
+
+func (ms ImportTaskModels) GetIds() (ids []string) {
+	for _, m := range ms {
+		ids = append(ids, cast.ToString(m.Id))
+	}
+	return ids
+}
+
+func (ms ImportTaskModels) IsAllSuccessed() bool {
+	for _, m := range ms {
+		if m.Status != Task_status_success {
+			return false
+		}
+	}
+	return true
+}
+
+type ImportTaskRepository struct {
+	table sqlbuilder.TableConfig
+}
+
+func NewImportTaskRepository(table sqlbuilder.TableConfig) *ImportTaskRepository {
+	return &ImportTaskRepository{
+		table: table,
+	}
+}
+
+type ImportTaskRepositoryAddIn struct {
+	ConfigKey string `json:"configKey"`
+	AppId     string `json:"appId"`
+	CreatorId string `json:"creatorId"`
+	Filename  string `json:"filename"`
+	Remark    string `json:"remark"`
+}
+
+func (in ImportTaskRepositoryAddIn) Fields() sqlbuilder.Fields {
+	return sqlbuilder.Fields{
+		NewConfigKey(in.ConfigKey).SetRequired(true),
+		NewAppId(in.AppId).SetRequired(true),
+		NewCreatorId(in.CreatorId).SetRequired(true),
+		NewFilename(in.Filename).SetRequired(true),
+		NewStatus(Task_status_importing),
+		NewRemark(in.Remark),
+	}
+}
+
+func (s ImportTaskRepository) Add(in ImportTaskRepositoryAddIn) (id uint64, err error) {
+	id, _, err = s.table.Repository().InsertWithLastId(in.Fields())
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+type ImportTaskRepositoryUpdateStatusIn struct {
+	Id        int    `json:"id"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Url       string `json:"url"`
+	Remark    string `json:"remark"`
+}
+
+func (in ImportTaskRepositoryUpdateStatusIn) Fields() sqlbuilder.Fields {
+	return sqlbuilder.Fields{
+		NewId(in.Id).SetRequired(true).AppendWhereFn(sqlbuilder.ValueFnForward),
+		NewStatus(in.Status).SetRequired(true),
+		NewTotal(in.Total),
+		NewSucceeded(in.Succeeded),
+		NewFailed(in.Failed),
+		NewUrl(in.Url),
+		NewRemark(in.Remark),
+	}
+}
+
+// UpdateStatus 更新任务状态及导入结果,并发布 changeStatus 事件
+func (s ImportTaskRepository) UpdateStatus(in ImportTaskRepositoryUpdateStatusIn) (err error) {
+	err = s.table.Repository().Update(in.Fields())
+	if err != nil {
+		return err
+	}
+	err = s.PublishEvent(cast.ToString(in.Id))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type ImportTaskRepositoryUpdateProgressIn struct {
+	Id        int `json:"id"`
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+func (in ImportTaskRepositoryUpdateProgressIn) Fields() sqlbuilder.Fields {
+	return sqlbuilder.Fields{
+		NewId(in.Id).SetRequired(true).AppendWhereFn(sqlbuilder.ValueFnForward),
+		NewTotal(in.Total),
+		NewSucceeded(in.Succeeded),
+		NewFailed(in.Failed),
+	}
+}
+
+// UpdateProgress 更新导入进度(已处理行数),不修改任务状态、不发布事件
+func (s ImportTaskRepository) UpdateProgress(in ImportTaskRepositoryUpdateProgressIn) (err error) {
+	err = s.table.Repository().Update(in.Fields())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s ImportTaskRepository) PublishEvent(id string) (err error) {
+	event := sqlbuilder.IdentityEvent{
+		Operation:         ChangeStatus_EventId,
+		IdentityValue:     cast.ToString(id),
+		IdentityFieldName: sqlbuilder.GetFieldName(NewId),
+	}
+	err = s.table.Publish(event)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s ImportTaskRepository) GetByIds(ids ...string) (models ImportTaskModels, err error) {
+	fs := sqlbuilder.Fields{
+		NewId(0).SetRequired(true).AppendWhereFn(sqlbuilder.ValueFnForward).Apply(func(f *sqlbuilder.Field, fs ...*sqlbuilder.Field) {
+			f.ValueFns.ResetSetValueFn(func(inputValue any, f *sqlbuilder.Field, fs ...*sqlbuilder.Field) (any, error) {
+				return ids, nil
+			})
+		}).SetDelayApply(func(f *sqlbuilder.Field, fs ...*sqlbuilder.Field) {
+			columns := f.GetTable().Columns.DbNameWithAlias().AsAny()
+			f.SetSelectColumns(columns...)
+		}),
+	}
+	err = s.table.Repository().All(&models, fs)
+	if err != nil {
+		return nil, err
+	}
+	return models, nil
+}
@@ -0,0 +1,16 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suifengpiao14/excelrw/repository"
+)
+
+func TestImportTaskTableDDL(t *testing.T) {
+	ddl, err := repository.Import_task_table.GenerateDDL()
+	require.NoError(t, err)
+	fmt.Println(ddl)
+	require.Equal(t, "import:12", repository.ImportDependTaskId(12))
+}
@@ -56,59 +56,26 @@ func SubscribeTaskFinishedEvent() (consumerMaker func(table sqlbuilder.TableConf
 			//如果状态为成功，则发起callback请求
 			switch model.Status {
 			case Task_status_success:
-				requestService := NewRequestLogRepository(table)
-				taskId := cast.ToString(model.Id)
-				requests, err := requestService.GetByDependTaskId(taskId)
-				if err != nil {
-					return err
-				}
-				taskService := NewExportTaskRepository(publishTable)
-				for _, request := range requests {
-					reqDTO, err := request.ParseReqeuestDTO()
-					if err != nil {
-						updateIn := RequestLogRepositoryUpdateResponseIn{
-							Id:     request.Id,
-							Result: ReqeustLog_result_fail,
-							Error:  err.Error(),
-						}
-						err = requestService.UpdateResponse(updateIn)
-						if err != nil {
-							return err
-						}
-						continue
-					}
-
-					dependTaskIds := strings.Split(request.DependTaskId, ",")
-					models, err := taskService.GetByIds(dependTaskIds...)
-					if err != nil {
-						return err
-					}
-					isSuccessed := models.IsAllSuccessed()
-					if isSuccessed {
-						proxyRequest := ProxyRequest{
-							ReqDTO:           *reqDTO,
-							BusinessCodePath: request.BusinessCodePath,
-							BusinessOkCode:   request.BusinessOkCode,
-						}
-						resp, err := proxyRequest.Request()
-						if err != nil {
-							resp.Result = ReqeustLog_result_fail
-							resp.Error = err.Error()
-						}
-						responseIn := RequestLogRepositoryUpdateResponseIn{
-							Id:          request.Id,
-							ResponseDTO: resp.RespDTO.String(),
-							HttpCode:    cast.ToString(resp.HttpCode),
-							Result:      resp.Result,
-							Error:       resp.Error,
-						}
-						err = requestService.UpdateResponse(responseIn)
-						if err != nil {
-							return err
-						}
-					}
-				}
+				return dealDependRequests(table, cast.ToString(model.Id))
+			case Task_status_failed:
+				//todo 有任务失败，应该将依赖的回调函数也标记为失败
+			}
+			return nil
+		})
+	}
+}
 
+// SubscribeImportTaskFinishedEvent 订阅导入任务状态变更事件,导入完成后发起依赖该任务的回调请求;默认不订阅,使用 NewRequestLogRepositoryWithImportTask 开启
+func SubscribeImportTaskFinishedEvent() (consumerMaker func(table sqlbuilder.TableConfig) (consumer sqlbuilder.Consumer)) {
+	return func(table sqlbuilder.TableConfig) (consumer sqlbuilder.Consumer) {
+		publishTable, err := table.GetTopicTable(Import_task_table)
+		if err != nil {
+			panic(err)
+		}
+		return sqlbuilder.MakeIdentityEventSubscriber(publishTable, func(model ImportTaskModel) (err error) {
+			switch model.Status {
+			case Task_status_success:
+				return dealDependRequests(table, ImportDependTaskId(model.Id))
 			case Task_status_failed:
 				//todo 有任务失败，应该将依赖的回调函数也标记为失败
 			}
@@ -117,6 +84,98 @@ func SubscribeTaskFinishedEvent() (consumerMaker func(table sqlbuilder.TableConf
 	}
 }
 
+// dealDependRequests 依赖任务全部成功时发起回调请求
+func dealDependRequests(table sqlbuilder.TableConfig, taskId string) (err error) {
+	requestService := NewRequestLogRepository(table)
+	requests, err := requestService.GetByDependTaskId(taskId)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		reqDTO, err := request.ParseReqeuestDTO()
+		if err != nil {
+			updateIn := RequestLogRepositoryUpdateResponseIn{
+				Id:     request.Id,
+				Result: ReqeustLog_result_fail,
+				Error:  err.Error(),
+			}
+			err = requestService.UpdateResponse(updateIn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		isSuccessed, err := isDependTasksSuccessed(table, strings.Split(request.DependTaskId, ","))
+		if err != nil {
+			return err
+		}
+		if isSuccessed {
+			proxyRequest := ProxyRequest{
+				ReqDTO:           *reqDTO,
+				BusinessCodePath: request.BusinessCodePath,
+				BusinessOkCode:   request.BusinessOkCode,
+			}
+			resp, err := proxyRequest.Request()
+			if err != nil {
+				resp.Result = ReqeustLog_result_fail
+				resp.Error = err.Error()
+			}
+			responseIn := RequestLogRepositoryUpdateResponseIn{
+				Id:          request.Id,
+				ResponseDTO: resp.RespDTO.String(),
+				HttpCode:    cast.ToString(resp.HttpCode),
+				Result:      resp.Result,
+				Error:       resp.Error,
+			}
+			err = requestService.UpdateResponse(responseIn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isDependTasksSuccessed 依赖的导出、导入任务(ImportDependTaskId 生成的ID)是否全部成功
+func isDependTasksSuccessed(table sqlbuilder.TableConfig, dependTaskIds []string) (isSuccessed bool, err error) {
+	exportTaskIds, importTaskIds := make([]string, 0), make([]string, 0)
+	for _, id := range dependTaskIds {
+		if importTaskId, ok := strings.CutPrefix(id, ImportDependTaskId_prefix); ok {
+			importTaskIds = append(importTaskIds, importTaskId)
+			continue
+		}
+		exportTaskIds = append(exportTaskIds, id)
+	}
+	if len(exportTaskIds) > 0 {
+		exportTable, err := table.GetTopicTable(Export_export_task_table)
+		if err != nil {
+			return false, err
+		}
+		models, err := NewExportTaskRepository(exportTable).GetByIds(exportTaskIds...)
+		if err != nil {
+			return false, err
+		}
+		if !models.IsAllSuccessed() {
+			return false, nil
+		}
+	}
+	if len(importTaskIds) > 0 {
+		importTable, err := table.GetTopicTable(Import_task_table)
+		if err != nil {
+			return false, err
+		}
+		models, err := NewImportTaskRepository(importTable).GetByIds(importTaskIds...)
+		if err != nil {
+			return false, err
+		}
+		if !models.IsAllSuccessed() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 type ProxyRequest struct {
 	ReqDTO           httpraw.RequestDTO `json:"reqDTO"`
 	BusinessCodePath string             `json:"businessCodePath"` //业务成功标识路径，例如：$.code
@@ -194,11 +253,20 @@ type RequestLogRepository struct {
 }
 
 func NewRequestLogRepository(table sqlbuilder.TableConfig) (repository *RequestLogRepository) {
+	return newRequestLogRepository(table, SubscribeTaskFinishedEvent())
+}
+
+// NewRequestLogRepositoryWithImportTask 同 NewRequestLogRepository,同时订阅导入任务完成事件(table 需关联导入任务表 Import_task_table)
+func NewRequestLogRepositoryWithImportTask(table sqlbuilder.TableConfig) (repository *RequestLogRepository) {
+	return newRequestLogRepository(table, SubscribeTaskFinishedEvent(), SubscribeImportTaskFinishedEvent())
+}
+
+func newRequestLogRepository(table sqlbuilder.TableConfig, consumerMakers ...func(table sqlbuilder.TableConfig) (consumer sqlbuilder.Consumer)) (repository *RequestLogRepository) {
 	err := table.CheckMissOutFieldName(Request_log_table)
 	if err != nil {
 		panic(err)
 	}
-	table = table.WithConsumerMakers(SubscribeTaskFinishedEvent())
+	table = table.WithConsumerMakers(consumerMakers...)
 	err = table.Init() //启动消费者
 	if err != nil {
 		panic(err)
@@ -0,0 +1,125 @@
+package excelrw
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/suifengpiao14/excelrw/defined"
+	"github.com/xuri/excelize/v2"
+)
+
+// _StyleRegistry 样式注册缓存,相同样式只向 excelize 注册一次
+type _StyleRegistry struct {
+	fd   *excelize.File
+	ids  map[string]int
+	lock sync.Mutex
+}
+
+func newStyleRegistry(fd *excelize.File) *_StyleRegistry {
+	return &_StyleRegistry{fd: fd, ids: make(map[string]int)}
+}
+
+// GetStyleID 合并样式(后者覆盖前者)并获取样式ID,样式为空返回0
+func (r *_StyleRegistry) GetStyleID(styles ...*defined.CellStyle) (styleID int, err error) {
+	var merged *defined.CellStyle
+	for _, style := range styles {
+		if style == nil {
+			continue
+		}
+		merged = merged.Merge(style)
+	}
+	if merged == nil {
+		return 0, nil
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return 0, err
+	}
+	key := string(b)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if styleID, ok := r.ids[key]; ok {
+		return styleID, nil
+	}
+	styleID, err = r.fd.NewStyle(toExcelizeStyle(merged))
+	if err != nil {
+		return 0, err
+	}
+	r.ids[key] = styleID
+	return styleID, nil
+}
+
+// GetUnlockedStyleID 获取已注册样式取消锁定后的样式ID(用于模板样式)
+func (r *_StyleRegistry) GetUnlockedStyleID(styleID int) (unlockedStyleID int, err error) {
+	key := fmt.Sprintf("unlocked:%d", styleID)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if unlockedStyleID, ok := r.ids[key]; ok {
+		return unlockedStyleID, nil
+	}
+	style, err := r.fd.GetStyle(styleID)
+	if err != nil {
+		return 0, err
+	}
+	style.Protection = &excelize.Protection{Locked: false}
+	unlockedStyleID, err = r.fd.NewStyle(style)
+	if err != nil {
+		return 0, err
+	}
+	r.ids[key] = unlockedStyleID
+	return unlockedStyleID, nil
+}
+
+func toExcelizeStyle(style *defined.CellStyle) (excelizeStyle *excelize.Style) {
+	excelizeStyle = &excelize.Style{}
+	excelizeStyle.Font = toExcelizeFont(style.Font)
+	if style.Fill != "" {
+		excelizeStyle.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{style.Fill}}
+	}
+	if style.Border != "" {
+		for _, borderType := range []string{"left", "top", "right", "bottom"} {
+			excelizeStyle.Border = append(excelizeStyle.Border, excelize.Border{Type: borderType, Color: style.Border, Style: 1})
+		}
+	}
+	if style.Horizontal != "" || style.Vertical != "" || style.WrapText {
+		excelizeStyle.Alignment = &excelize.Alignment{
+			Horizontal: style.Horizontal,
+			Vertical:   style.Vertical,
+			WrapText:   style.WrapText,
+		}
+	}
+	if style.Unlocked {
+		excelizeStyle.Protection = &excelize.Protection{Locked: false}
+	}
+	if style.NumFmt != "" {
+		numFmt := style.NumFmt
+		excelizeStyle.CustomNumFmt = &numFmt
+	}
+	return excelizeStyle
+}
+
+func toExcelizeFont(font *defined.FontStyle) (excelizeFont *excelize.Font) {
+	if font == nil {
+		return nil
+	}
+	excelizeFont = &excelize.Font{
+		Bold:   font.Bold,
+		Italic: font.Italic,
+		Size:   font.Size,
+		Color:  font.Color,
+		Family: font.Family,
+	}
+	if font.Underline {
+		excelizeFont.Underline = "single"
+	}
+	return excelizeFont
+}
+
+func toRichTextRuns(runs []defined.RichTextRun) (excelizeRuns []excelize.RichTextRun) {
+	excelizeRuns = make([]excelize.RichTextRun, 0, len(runs))
+	for _, run := range runs {
+		excelizeRuns = append(excelizeRuns, excelize.RichTextRun{Text: run.Text, Font: toExcelizeFont(run.Font)})
+	}
+	return excelizeRuns
+}